@@ -4,12 +4,20 @@ go 1.16
 
 require (
 	github.com/blang/semver/v4 v4.0.0
+	github.com/deislabs/oras v0.8.1
+	github.com/docker/distribution v2.7.1+incompatible
+	github.com/opencontainers/image-spec v1.0.1
 	github.com/sirupsen/logrus v1.7.0
 	github.com/spf13/afero v1.2.2
+	github.com/spf13/cobra v1.1.1
 	github.com/spf13/pflag v1.0.5
+	golang.org/x/mod v0.3.0
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 	helm.sh/helm/v3 v3.5.0
 	k8s.io/api v0.20.4
+	k8s.io/apiextensions-apiserver v0.20.1
 	k8s.io/apimachinery v0.20.4
+	k8s.io/client-go v0.20.4
 	rsc.io/letsencrypt v0.0.3 // indirect
 	sigs.k8s.io/kubebuilder/v3 v3.1.0
 	sigs.k8s.io/yaml v1.2.0
@@ -18,116 +18,139 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 )
 
-// RemoveKustomizeCRDManifests removes items in config/crd relating to CRD conversion webhooks.
-func RemoveKustomizeCRDManifests() error {
+// RemoveKustomizeCRDManifests removes items in config/crd of fs relating to CRD conversion webhooks,
+// unless they are referenced by config/crd/kustomization.yaml because a conversion webhook was created.
+func RemoveKustomizeCRDManifests(fs afero.Fs) error {
 
 	pathsToRemove := []string{
 		filepath.Join("config", "crd", "kustomizeconfig.yaml"),
 	}
 	configPatchesDir := filepath.Join("config", "crd", "patches")
-	webhookPatchMatches, err := filepath.Glob(filepath.Join(configPatchesDir, "webhook_in_*.yaml"))
+	webhookPatchMatches, err := afero.Glob(fs, filepath.Join(configPatchesDir, "webhook_in_*.yaml"))
 	if err != nil {
 		return err
 	}
 	pathsToRemove = append(pathsToRemove, webhookPatchMatches...)
-	cainjectionPatchMatches, err := filepath.Glob(filepath.Join(configPatchesDir, "cainjection_in_*.yaml"))
+	cainjectionPatchMatches, err := afero.Glob(fs, filepath.Join(configPatchesDir, "cainjection_in_*.yaml"))
 	if err != nil {
 		return err
 	}
 	pathsToRemove = append(pathsToRemove, cainjectionPatchMatches...)
+
+	referenced, err := kustomizationEntries(fs, filepath.Join("config", "crd", "kustomization.yaml"))
+	if err != nil {
+		return err
+	}
 	for _, p := range pathsToRemove {
-		if err := os.RemoveAll(p); err != nil {
+		rel, err := filepath.Rel(filepath.Join("config", "crd"), p)
+		if err != nil {
+			return err
+		}
+		if referenced[filepath.ToSlash(rel)] {
+			continue
+		}
+		if err := fs.RemoveAll(p); err != nil {
 			return err
 		}
 	}
-	children, err := ioutil.ReadDir(configPatchesDir)
+	children, err := afero.ReadDir(fs, configPatchesDir)
 	if err == nil && len(children) == 0 {
-		if err := os.RemoveAll(configPatchesDir); err != nil {
+		if err := fs.RemoveAll(configPatchesDir); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// UpdateKustomizationsInit updates certain parts of or removes entire kustomization.yaml files
-// that are either not used by certain Init plugins or are created by preceding Init plugins.
-func UpdateKustomizationsInit() error {
-
-	defaultKFile := filepath.Join("config", "default", "kustomization.yaml")
-	if err := ReplaceInFile(defaultKFile,
-		`
-# [WEBHOOK] To enable webhook, uncomment all the sections with [WEBHOOK] prefix including the one in
-# crd/kustomization.yaml
-#- ../webhook
-# [CERTMANAGER] To enable cert-manager, uncomment all sections with 'CERTMANAGER'. 'WEBHOOK' components are required.
-#- ../certmanager`, ""); err != nil {
-		return fmt.Errorf("remove %s resources: %v", defaultKFile, err)
+// kustomizationEntries returns the uncommented list entries of the kustomization file at path in fs.
+func kustomizationEntries(fs afero.Fs, path string) (map[string]bool, error) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	entries := map[string]bool{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if entry := strings.TrimPrefix(strings.TrimSpace(line), "- "); entry != strings.TrimSpace(line) {
+			entries[entry] = true
+		}
+	}
+	return entries, nil
+}
 
-	if err := ReplaceInFile(defaultKFile,
-		`
-# [WEBHOOK] To enable webhook, uncomment all the sections with [WEBHOOK] prefix including the one in
-# crd/kustomization.yaml
-#- manager_webhook_patch.yaml
+// UpdateKustomizationsCreateWebhook uncomments the sections of config/default/kustomization.yaml
+// of fs that deploy the webhook server, its cert-manager certificate and the variables they use.
+// The files they reference must have been scaffolded first.
+func UpdateKustomizationsCreateWebhook(fs afero.Fs) error {
 
-# [CERTMANAGER] To enable cert-manager, uncomment all sections with 'CERTMANAGER'.
-# Uncomment 'CERTMANAGER' sections in crd/kustomization.yaml to enable the CA injection in the admission webhooks.
-# 'CERTMANAGER' needs to be enabled to use ca injection
-#- webhookcainjection_patch.yaml
+	defaultKFile := filepath.Join("config", "default", "kustomization.yaml")
+	info, err := fs.Stat(defaultKFile)
+	if err != nil {
+		return err
+	}
+	b, err := afero.ReadFile(fs, defaultKFile)
+	if err != nil {
+		return err
+	}
 
-# the following config is for teaching kustomize how to do var substitution
-vars:
-# [CERTMANAGER] To enable cert-manager, uncomment all sections with 'CERTMANAGER' prefix.
-#- name: CERTIFICATE_NAMESPACE # namespace of the certificate CR
-#  objref:
-#    kind: Certificate
-#    group: cert-manager.io
-#    version: v1
-#    name: serving-cert # this name should match the one in certificate.yaml
-#  fieldref:
-#    fieldpath: metadata.namespace
-#- name: CERTIFICATE_NAME
-#  objref:
-#    kind: Certificate
-#    group: cert-manager.io
-#    version: v1
-#    name: serving-cert # this name should match the one in certificate.yaml
-#- name: SERVICE_NAMESPACE # namespace of the service
-#  objref:
-#    kind: Service
-#    version: v1
-#    name: webhook-service
-#  fieldref:
-#    fieldpath: metadata.namespace
-#- name: SERVICE_NAME
-#  objref:
-#    kind: Service
-#    version: v1
-#    name: webhook-service
-`, ""); err != nil {
-		return fmt.Errorf("remove %s patch and vars blocks: %v", defaultKFile, err)
+	enabled := map[string]bool{}
+	lines := strings.Split(string(b), "\n")
+	inVars := false
+	for i, line := range lines {
+		switch {
+		case line == "vars:":
+			inVars = true
+		case inVars && (strings.HasPrefix(line, "#-") || strings.HasPrefix(line, "#  ")):
+			lines[i] = strings.TrimPrefix(line, "#")
+		case inVars && !strings.HasPrefix(line, "#"):
+			inVars = false
+		}
+		for _, entry := range webhookKustomizeEntries {
+			if lines[i] == "#- "+entry {
+				lines[i] = "- " + entry
+			}
+			if lines[i] == "- "+entry {
+				enabled[entry] = true
+			}
+		}
+	}
+	for _, entry := range webhookKustomizeEntries {
+		if !enabled[entry] {
+			return fmt.Errorf("unable to find the %q entry in %s, add it to enable the webhooks", entry, defaultKFile)
+		}
 	}
 
-	return nil
+	return afero.WriteFile(fs, defaultKFile, []byte(strings.Join(lines, "\n")), info.Mode())
+}
+
+// webhookKustomizeEntries are the entries of config/default/kustomization.yaml that deploy the webhooks.
+var webhookKustomizeEntries = []string{
+	"../webhook",
+	"../certmanager",
+	"manager_webhook_patch.yaml",
+	"webhookcainjection_patch.yaml",
 }
 
 // UpdateKustomizationsCreateAPI updates certain parts of or removes entire kustomization.yaml files
-// that are either not used by certain CreateAPI plugins or are created by preceding CreateAPI plugins.
-func UpdateKustomizationsCreateAPI() error {
+// of fs that are either not used by certain CreateAPI plugins or are created by preceding CreateAPI plugins.
+func UpdateKustomizationsCreateAPI(fs afero.Fs) error {
 
 	crdKFile := filepath.Join("config", "crd", "kustomization.yaml")
-	if crdKBytes, err := ioutil.ReadFile(crdKFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+	if crdKBytes, err := afero.ReadFile(fs, crdKFile); err != nil && !errors.Is(err, os.ErrNotExist) {
 		log.Debugf("Error reading kustomization for substitution: %v", err)
 	} else if err == nil {
 		if bytes.Contains(crdKBytes, []byte("[WEBHOOK]")) || bytes.Contains(crdKBytes, []byte("[CERTMANAGER]")) {
-			if err := os.RemoveAll(crdKFile); err != nil {
+			if err := fs.RemoveAll(crdKFile); err != nil {
 				log.Debugf("Error removing file prior to scaffold: %v", err)
 			}
 		}
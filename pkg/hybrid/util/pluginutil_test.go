@@ -0,0 +1,151 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// writeFiles returns an in-memory filesystem with files, keyed by their slash separated path.
+func writeFiles(t *testing.T, files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, filepath.FromSlash(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+// listFiles returns the slash separated paths of the files in fs, in order.
+func listFiles(t *testing.T, fs afero.Fs) []string {
+	files := []string{}
+	err := afero.Walk(fs, "", func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, filepath.ToSlash(path))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestRemoveKustomizeCRDManifests(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "without kustomization",
+			files: map[string]string{
+				"config/crd/kustomizeconfig.yaml":                    "nameReference:\n",
+				"config/crd/patches/webhook_in_memcacheds.yaml":      "spec:\n",
+				"config/crd/patches/cainjection_in_memcacheds.yaml":  "metadata:\n",
+				"config/crd/bases/cache.example.com_memcacheds.yaml": "kind: CustomResourceDefinition\n",
+			},
+			want: []string{"config/crd/bases/cache.example.com_memcacheds.yaml"},
+		},
+		{
+			name: "conversion webhook patches referenced by the kustomization",
+			files: map[string]string{
+				"config/crd/kustomization.yaml": "resources:\n- bases/cache.example.com_memcacheds.yaml\n" +
+					"patchesStrategicMerge:\n- patches/webhook_in_memcacheds.yaml\n" +
+					"#- patches/cainjection_in_memcacheds.yaml\n" +
+					"configurations:\n- kustomizeconfig.yaml\n",
+				"config/crd/kustomizeconfig.yaml":                    "nameReference:\n",
+				"config/crd/patches/webhook_in_memcacheds.yaml":      "spec:\n",
+				"config/crd/patches/cainjection_in_memcacheds.yaml":  "metadata:\n",
+				"config/crd/patches/webhook_in_redis.yaml":           "spec:\n",
+				"config/crd/bases/cache.example.com_memcacheds.yaml": "kind: CustomResourceDefinition\n",
+			},
+			want: []string{
+				"config/crd/bases/cache.example.com_memcacheds.yaml",
+				"config/crd/kustomization.yaml",
+				"config/crd/kustomizeconfig.yaml",
+				"config/crd/patches/webhook_in_memcacheds.yaml",
+			},
+		},
+		{
+			name: "other patches are kept",
+			files: map[string]string{
+				"config/crd/patches/webhook_in_memcacheds.yaml": "spec:\n",
+				"config/crd/patches/other_patch.yaml":           "spec:\n",
+			},
+			want: []string{"config/crd/patches/other_patch.yaml"},
+		},
+		{
+			name:  "no CRD directory",
+			files: map[string]string{"config/default/kustomization.yaml": "resources:\n"},
+			want:  []string{"config/default/kustomization.yaml"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := writeFiles(t, tt.files)
+			checkErr(t, RemoveKustomizeCRDManifests(fs), "")
+			if files := listFiles(t, fs); !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("expected files:\n%v\ngot:\n%v", tt.want, files)
+			}
+		})
+	}
+}
+
+func TestUpdateKustomizationsCreateAPI(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "kustomization with the webhook sections of the Go plugin",
+			files: map[string]string{
+				"config/crd/kustomization.yaml": "resources:\n" +
+					"patchesStrategicMerge:\n# [WEBHOOK] To enable webhook, uncomment all the sections with [WEBHOOK] prefix.\n",
+			},
+			want: []string{},
+		},
+		{
+			name: "kustomization scaffolded by this plugin",
+			files: map[string]string{
+				"config/crd/kustomization.yaml": "resources:\n- bases/cache.example.com_memcacheds.yaml\n",
+			},
+			want: []string{"config/crd/kustomization.yaml"},
+		},
+		{
+			name:  "no kustomization",
+			files: map[string]string{},
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := writeFiles(t, tt.files)
+			checkErr(t, UpdateKustomizationsCreateAPI(fs), "")
+			if files := listFiles(t, fs); !reflect.DeepEqual(files, tt.want) {
+				t.Errorf("expected files:\n%v\ngot:\n%v", tt.want, files)
+			}
+		})
+	}
+}
@@ -17,18 +17,19 @@ package util
 
 import (
 	"errors"
-	"io/ioutil"
-	"os"
 	"regexp"
 	"strings"
+
+	"github.com/spf13/afero"
 )
 
-func ReplaceInFile(path, old, new string) error {
-	info, err := os.Stat(path)
+// ReplaceInFile replaces every occurrence of old with new in the file at path of fs.
+func ReplaceInFile(fs afero.Fs, path, old, new string) error {
+	info, err := fs.Stat(path)
 	if err != nil {
 		return err
 	}
-	b, err := ioutil.ReadFile(path)
+	b, err := afero.ReadFile(fs, path)
 	if err != nil {
 		return err
 	}
@@ -36,23 +37,25 @@ func ReplaceInFile(path, old, new string) error {
 		return errors.New("unable to find the content to be replaced")
 	}
 	s := strings.Replace(string(b), old, new, -1)
-	err = ioutil.WriteFile(path, []byte(s), info.Mode())
+	err = afero.WriteFile(fs, path, []byte(s), info.Mode())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func ReplaceRegexInFile(path, match, replace string) error {
+// ReplaceRegexInFile replaces every match of the regular expression match with replace
+// in the file at path of fs.
+func ReplaceRegexInFile(fs afero.Fs, path, match, replace string) error {
 	matcher, err := regexp.Compile(match)
 	if err != nil {
 		return err
 	}
-	info, err := os.Stat(path)
+	info, err := fs.Stat(path)
 	if err != nil {
 		return err
 	}
-	b, err := ioutil.ReadFile(path)
+	b, err := afero.ReadFile(fs, path)
 	if err != nil {
 		return err
 	}
@@ -60,22 +63,27 @@ func ReplaceRegexInFile(path, match, replace string) error {
 	if s == string(b) {
 		return errors.New("unable to find the content to be replaced")
 	}
-	err = ioutil.WriteFile(path, []byte(s), info.Mode())
+	err = afero.WriteFile(fs, path, []byte(s), info.Mode())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-// InsertCode searches target content in the file and insert `toInsert` after the target.
-func InsertCode(filename, target, code string) error {
-	contents, err := ioutil.ReadFile(filename)
+// InsertCode searches target content in the file at path of fs and insert `code` after the target.
+func InsertCode(fs afero.Fs, path, target, code string) error {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return err
+	}
+	contents, err := afero.ReadFile(fs, path)
 	if err != nil {
 		return err
 	}
 	idx := strings.Index(string(contents), target)
+	if idx == -1 {
+		return errors.New("unable to find the target to insert the code after")
+	}
 	out := string(contents[:idx+len(target)]) + code + string(contents[idx+len(target):])
-	// false positive
-	// nolint:gosec
-	return ioutil.WriteFile(filename, []byte(out), 0644)
+	return afero.WriteFile(fs, path, []byte(out), info.Mode())
 }
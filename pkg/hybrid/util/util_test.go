@@ -0,0 +1,254 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+const testFile = "main.go"
+
+// newTestFs returns an in-memory filesystem with content written to testFile, if not nil.
+func newTestFs(t *testing.T, content *string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	if content != nil {
+		if err := afero.WriteFile(fs, testFile, []byte(*content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+// checkFile checks the content and the mode of testFile, which must be kept by the edits.
+func checkFile(t *testing.T, fs afero.Fs, want string) {
+	b, err := afero.ReadFile(fs, testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != want {
+		t.Errorf("expected content:\n%s\ngot:\n%s", want, b)
+	}
+	info, err := fs.Stat(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode() != 0600 {
+		t.Errorf("expected mode 0600, got %v", info.Mode())
+	}
+}
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestReplaceInFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string
+		old     string
+		new     string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "single occurrence",
+			content: strPtr("memory: 30Mi\ncpu: 100m\n"),
+			old:     "30Mi",
+			new:     "90Mi",
+			want:    "memory: 90Mi\ncpu: 100m\n",
+		},
+		{
+			name:    "every occurrence",
+			content: strPtr("a b a b a\n"),
+			old:     "a",
+			new:     "c",
+			want:    "c b c b c\n",
+		},
+		{
+			name:    "multiline content",
+			content: strPtr("func main() {\n}\n"),
+			old:     "{\n}",
+			new:     "{\n\tsetup()\n}",
+			want:    "func main() {\n\tsetup()\n}\n",
+		},
+		{
+			name:    "content not found",
+			content: strPtr("memory: 30Mi\n"),
+			old:     "20Mi",
+			new:     "60Mi",
+			want:    "memory: 30Mi\n",
+			wantErr: "unable to find the content to be replaced",
+		},
+		{
+			name:    "missing file",
+			old:     "a",
+			new:     "b",
+			wantErr: os.ErrNotExist.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestFs(t, tt.content)
+			checkErr(t, ReplaceInFile(fs, testFile, tt.old, tt.new), tt.wantErr)
+			if tt.content != nil {
+				checkFile(t, fs, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceRegexInFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string
+		match   string
+		replace string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "every match",
+			content: strPtr("memory: 30Mi\nmemory: 20Mi\n"),
+			match:   `memory: \d+Mi`,
+			replace: "memory: 64Mi",
+			want:    "memory: 64Mi\nmemory: 64Mi\n",
+		},
+		{
+			name:    "submatch expansion",
+			content: strPtr("Copyright 2020 Example.\n"),
+			match:   `Copyright (\d+) (.*)\.`,
+			replace: "Copyright $1 The $2 Authors.",
+			want:    "Copyright 2020 The Example Authors.\n",
+		},
+		{
+			name:    "multiline mode",
+			content: strPtr("IMG ?= controller:latest\nCRD_OPTIONS ?= crd\n"),
+			match:   `(?m)^IMG \?=.*$`,
+			replace: "IMG ?= example.com/operator:v0.0.1",
+			want:    "IMG ?= example.com/operator:v0.0.1\nCRD_OPTIONS ?= crd\n",
+		},
+		{
+			name:    "no match",
+			content: strPtr("memory: 30Mi\n"),
+			match:   `cpu: \d+m`,
+			replace: "cpu: 200m",
+			want:    "memory: 30Mi\n",
+			wantErr: "unable to find the content to be replaced",
+		},
+		{
+			name:    "match replaced with itself",
+			content: strPtr("memory: 30Mi\n"),
+			match:   `30Mi`,
+			replace: "30Mi",
+			want:    "memory: 30Mi\n",
+			wantErr: "unable to find the content to be replaced",
+		},
+		{
+			name:    "invalid regular expression",
+			content: strPtr("memory: 30Mi\n"),
+			match:   `memory: (\d+`,
+			want:    "memory: 30Mi\n",
+			wantErr: "missing closing )",
+		},
+		{
+			name:    "missing file",
+			match:   `a`,
+			replace: "b",
+			wantErr: os.ErrNotExist.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestFs(t, tt.content)
+			checkErr(t, ReplaceRegexInFile(fs, testFile, tt.match, tt.replace), tt.wantErr)
+			if tt.content != nil {
+				checkFile(t, fs, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		content *string
+		target  string
+		code    string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "after the target",
+			content: strPtr("import (\n\t\"os\"\n)\n"),
+			target:  "import (\n",
+			code:    "\t\"fmt\"\n",
+			want:    "import (\n\t\"fmt\"\n\t\"os\"\n)\n",
+		},
+		{
+			name:    "after the first occurrence of the target",
+			content: strPtr("args:\n- a\nargs:\n- b\n"),
+			target:  "args:\n",
+			code:    "- c\n",
+			want:    "args:\n- c\n- a\nargs:\n- b\n",
+		},
+		{
+			name:    "at the end of the file",
+			content: strPtr("resources:\n- role.yaml\n"),
+			target:  "- role.yaml\n",
+			code:    "- role_binding.yaml\n",
+			want:    "resources:\n- role.yaml\n- role_binding.yaml\n",
+		},
+		{
+			name:    "target not found",
+			content: strPtr("resources:\n- role.yaml\n"),
+			target:  "bases:\n",
+			code:    "- ../crd\n",
+			want:    "resources:\n- role.yaml\n",
+			wantErr: "unable to find the target to insert the code after",
+		},
+		{
+			name:    "missing file",
+			target:  "a",
+			code:    "b",
+			wantErr: os.ErrNotExist.Error(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := newTestFs(t, tt.content)
+			checkErr(t, InsertCode(fs, testFile, tt.target, tt.code), tt.wantErr)
+			if tt.content != nil {
+				checkFile(t, fs, tt.want)
+			}
+		})
+	}
+}
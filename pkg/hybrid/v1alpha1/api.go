@@ -0,0 +1,328 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"helm.sh/helm/v3/pkg/chart"
+
+	sdkutil "github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/util"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/chartutil"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin/util"
+)
+
+const (
+	helmChartFlag        = "helm-chart"
+	helmChartRepoFlag    = "helm-chart-repo"
+	helmChartVersionFlag = "helm-chart-version"
+	inferSchemaFlag      = "infer-schema"
+	goTypesFlag          = "go-types"
+	controllerTypeFlag   = "controller-type"
+
+	watchDependentResourcesFlag = "watch-dependent-resources"
+	overrideValuesFlag          = "override-values"
+	reconcilePeriodFlag         = "reconcile-period"
+	maxConcurrentReconcilesFlag = "max-concurrent-reconciles"
+
+	// defaultCRDVersion is the CRD API version scaffolded for new kinds.
+	defaultCRDVersion = "v1"
+)
+
+// Types of controller reconciling a new kind.
+const (
+	// controllerTypeHelm kinds are reconciled by a Helm reconciler installing a chart
+	controllerTypeHelm = "helm"
+	// controllerTypeGo kinds are reconciled by a scaffolded controller-runtime reconciler
+	controllerTypeGo = "go"
+)
+
+type createAPISubcommand struct {
+	config config.Config
+
+	// flags is used to only set the watch options that are set
+	flags *pflag.FlagSet
+
+	chartOptions chartutil.Options
+	crdOptions   scaffolds.CRDOptions
+	rbacOptions  scaffolds.RBACOptions
+	watchOptions scaffolds.WatchOptions
+
+	// watchDependentResources is parsed into watchOptions
+	watchDependentResources bool
+
+	resource *resource.Resource
+	chart    *chart.Chart
+
+	// controllerType is the type of controller reconciling the new kind
+	controllerType string
+
+	// goTypes indicates whether Go types mirroring the values of the chart are scaffolded
+	goTypes bool
+
+	// runMake indicates whether to run "make generate" after scaffolding Go types
+	runMake bool
+
+	// namespaced indicates whether the scaffolded CRD is namespace-scoped
+	namespaced bool
+
+	// force indicates that the resource should be created even if it already exists
+	force bool
+}
+
+var _ plugin.CreateAPISubcommand = &createAPISubcommand{}
+
+// UpdateMetadata defines plugin context
+func (p *createAPISubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *plugin.SubcommandMetadata) {
+	subcmdMeta.Description = `Scaffold a Kubernetes API that is reconciled by a Helm chart:
+	- the chart is fetched if needed and copied into the "helm-charts" directory
+	- a CustomResourceDefinition is added under "config/crd", its spec is validated using the
+	  values.schema.json of the chart if any, or a schema inferred from values.yaml with --infer-schema
+	- a watch for the new kind is added to "watches.yaml", from which "main.go" creates its Helm reconciler;
+	  if the kind is already watched, its watch is replaced with --force, the options that are not set are removed
+	- with --go-types, Go types whose spec mirrors the values of the chart are added under "api"
+
+With --controller-type=go, scaffold a Kubernetes API that is reconciled by a Go controller instead:
+	- Go types for the new kind are added under "api"
+	- a controller-runtime reconciler is added under "controllers" and registered in "main.go"
+	- a CustomResourceDefinition is added under "config/crd"
+`
+	subcmdMeta.Examples = fmt.Sprintf(`  # Create a Memcached API backed by a chart in a local directory
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached --helm-chart=../memcached
+
+	# Create a Memcached API backed by a local chart, with Go types mirroring the values of the chart
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached --helm-chart=../memcached --go-types
+
+	# Create a Memcached API whose custom resources are reconciled again every minute, with 3 workers
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached --helm-chart=../memcached \
+		--reconcile-period=1m --max-concurrent-reconciles=3 --override-values=image.tag=1.6.9
+
+	# Create a Frigate API reconciled by a Go controller, in the same project
+	$ %[1]s create api --group ship --version v1beta1 --kind Frigate --controller-type=go
+
+	# Create a Memcached API backed by a packaged chart archive
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached --helm-chart=../memcached-0.1.0.tgz
+
+	# Create a Memcached API backed by the latest chart from a repository in your helm configuration
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached --helm-chart=bitnami/memcached
+
+	# Create a Memcached API backed by a specific chart version from a repository URL
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached \
+		--helm-chart=memcached --helm-chart-repo=https://charts.bitnami.com/bitnami --helm-chart-version=5.4.0
+
+	# Create a Memcached API backed by a chart archive URL
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached \
+		--helm-chart=https://example.com/charts/memcached-0.1.0.tgz
+
+	# Create a Memcached API backed by a chart in an OCI registry
+	$ %[1]s create api --group cache --version v1alpha1 --kind Memcached \
+		--helm-chart=oci://registry.example.com/charts/memcached --helm-chart-version=0.1.0
+`, cliMeta.CommandName)
+}
+
+// BindFlags binds the flags used to create an API
+func (p *createAPISubcommand) BindFlags(fs *pflag.FlagSet) {
+	p.flags = fs
+	fs.SortFlags = false
+
+	fs.StringVar(&p.controllerType, controllerTypeFlag, controllerTypeHelm,
+		fmt.Sprintf("type of controller reconciling the new kind, either %q (a Helm reconciler installing --%s) "+
+			"or %q (a scaffolded Go controller)", controllerTypeHelm, helmChartFlag, controllerTypeGo))
+
+	fs.StringVar(&p.chartOptions.Chart, helmChartFlag, "", "helm chart that reconciles the new kind: "+
+		"a local chart directory or archive, a <repo>/<name> reference, an archive URL or an oci:// reference")
+	fs.StringVar(&p.chartOptions.Repo, helmChartRepoFlag, "", "helm chart repository URL to fetch --"+helmChartFlag+" from")
+	fs.StringVar(&p.chartOptions.Version, helmChartVersionFlag, "", "helm chart version (default: latest)")
+
+	fs.BoolVar(&p.crdOptions.InferSchema, inferSchemaFlag, false, "infer the schema of the CRD spec from the "+
+		"default values of the chart if it has no values.schema.json, unknown fields are preserved")
+
+	fs.BoolVar(&p.goTypes, goTypesFlag, false, "scaffold Go types whose spec mirrors the default values of the chart, "+
+		"so that Go controllers can read the custom resources")
+	fs.BoolVar(&p.runMake, "make", true, "if true, run `make generate` after generating Go types or controllers")
+
+	bindRBACFlags(fs, &p.rbacOptions)
+
+	fs.BoolVar(&p.watchDependentResources, watchDependentResourcesFlag, true,
+		"watch the resources created by the chart, and reconcile the custom resource when they change")
+	fs.StringToStringVar(&p.watchOptions.OverrideValues, overrideValuesFlag, nil,
+		"chart values overriding those of the custom resources, as key=value pairs where the key is the "+
+			"path of the value (e.g. image.repository=quay.io/example/app), values may reference environment "+
+			"variables of the manager (e.g. $RELATED_IMAGE_APP)")
+	fs.DurationVar(&p.watchOptions.ReconcilePeriod, reconcilePeriodFlag, 0,
+		"period after which the custom resources are reconciled again, even if they did not change (e.g. 1m)")
+	fs.IntVar(&p.watchOptions.MaxConcurrentReconciles, maxConcurrentReconcilesFlag, 0,
+		"maximum number of custom resources reconciled concurrently (default: the number set by the manager)")
+
+	fs.BoolVar(&p.namespaced, "namespaced", true, "resource is namespaced")
+	fs.BoolVar(&p.force, "force", false, "attempt to create resource even if it already exists")
+}
+
+func (p *createAPISubcommand) InjectConfig(c config.Config) error {
+	p.config = c
+
+	return nil
+}
+
+func (p *createAPISubcommand) InjectResource(res *resource.Resource) error {
+	p.resource = res
+
+	switch p.controllerType {
+	case controllerTypeHelm:
+		if strings.TrimSpace(p.chartOptions.Chart) == "" {
+			return fmt.Errorf("--%s is required", helmChartFlag)
+		}
+		if p.chartOptions.Repo != "" && strings.Contains(p.chartOptions.Chart, "://") {
+			return fmt.Errorf("--%s must be a chart name when --%s is set", helmChartFlag, helmChartRepoFlag)
+		}
+	case controllerTypeGo:
+		if p.chartOptions != (chartutil.Options{}) {
+			return fmt.Errorf("--%s, --%s and --%s can only be used with --%s=%s", helmChartFlag,
+				helmChartRepoFlag, helmChartVersionFlag, controllerTypeFlag, controllerTypeHelm)
+		}
+		if p.flags.Changed(watchDependentResourcesFlag) || p.watchOptions.OverrideValues != nil ||
+			p.watchOptions.ReconcilePeriod != 0 || p.watchOptions.MaxConcurrentReconciles != 0 {
+			return fmt.Errorf("--%s, --%s, --%s and --%s can only be used with --%s=%s",
+				watchDependentResourcesFlag, overrideValuesFlag, reconcilePeriodFlag, maxConcurrentReconcilesFlag,
+				controllerTypeFlag, controllerTypeHelm)
+		}
+	default:
+		return fmt.Errorf("invalid value %q for --%s, must be either %q or %q", p.controllerType,
+			controllerTypeFlag, controllerTypeHelm, controllerTypeGo)
+	}
+
+	if err := validateRBACOptions(p.rbacOptions); err != nil {
+		return err
+	}
+
+	if err := p.parseWatchOptions(); err != nil {
+		return err
+	}
+
+	// Helm-backed kinds have no controller of their own, they are reconciled by
+	// a Helm reconciler and only have Go types if requested. Go kinds always
+	// have Go types, used by their controller.
+	p.resource.Path = ""
+	if p.goTypes || p.controllerType == controllerTypeGo {
+		p.resource.Path = resource.APIPackagePath(p.config.GetRepository(), p.resource.Group, p.resource.Version,
+			p.config.IsMultiGroup())
+	}
+	p.resource.Controller = p.controllerType == controllerTypeGo
+	p.resource.API = &resource.API{
+		CRDVersion: defaultCRDVersion,
+		Namespaced: p.namespaced,
+	}
+
+	if err := p.resource.Validate(); err != nil {
+		return err
+	}
+
+	// Check that resource doesn't have the API scaffolded or flag force was set
+	if r, err := p.config.GetResource(p.resource.GVK); err == nil && r.HasAPI() && !p.force {
+		return errors.New("API resource already exists")
+	}
+
+	// controller-gen generates the CRDs of all the kinds in the packages of the Go kinds, which would
+	// overwrite the CRDs generated from the charts of the chart-backed kinds with Go types
+	resources, err := p.config.GetResources()
+	if err != nil {
+		return err
+	}
+	for _, r := range resources {
+		if p.resource.Path != "" && r.Path == p.resource.Path && r.GVK != p.resource.GVK &&
+			r.HasController() != p.resource.Controller {
+			return fmt.Errorf("kind %s has its Go types in package %s, the Go types of the kinds reconciled "+
+				"by Go controllers and of the chart-backed kinds must be in different packages, "+
+				"use another version", r.Kind, r.Path)
+		}
+	}
+
+	// Check that the provided group can be added to the project
+	if !p.config.IsMultiGroup() && p.config.ResourcesLength() != 0 && !p.config.HasGroup(p.resource.Group) {
+		return fmt.Errorf("multiple groups are not allowed by default, " +
+			"to enable multi-group visit https://kubebuilder.io/migration/multi-group.html")
+	}
+
+	if p.controllerType == controllerTypeHelm {
+		chrt, err := chartutil.LoadChart(p.chartOptions)
+		if err != nil {
+			return err
+		}
+		p.chart = chrt
+	}
+
+	return nil
+}
+
+// parseWatchOptions validates the options of the watch of the new kind, and sets the ones that
+// can not be bound directly to a flag.
+func (p *createAPISubcommand) parseWatchOptions() error {
+	// Only set if the flag is set, the default of the Helm reconciler applies otherwise
+	if p.flags.Changed(watchDependentResourcesFlag) {
+		p.watchOptions.WatchDependentResources = &p.watchDependentResources
+	}
+	if p.watchOptions.ReconcilePeriod < 0 {
+		return fmt.Errorf("invalid value %q for --%s, must not be negative",
+			p.watchOptions.ReconcilePeriod, reconcilePeriodFlag)
+	}
+	if p.watchOptions.MaxConcurrentReconciles < 0 {
+		return fmt.Errorf("invalid value %d for --%s, must not be negative",
+			p.watchOptions.MaxConcurrentReconciles, maxConcurrentReconcilesFlag)
+	}
+
+	return nil
+}
+
+func (p *createAPISubcommand) Scaffold(fs machinery.Filesystem) error {
+	// Remove the CRD kustomize files scaffolded by a preceding plugin, since the CRDs are
+	// scaffolded by this plugin, and conversion webhooks are only enabled for the kinds
+	// they are created for by "create webhook --conversion".
+	if err := sdkutil.UpdateKustomizationsCreateAPI(fs.FS); err != nil {
+		return fmt.Errorf("error updating kustomization.yaml files: %v", err)
+	}
+	if err := sdkutil.RemoveKustomizeCRDManifests(fs.FS); err != nil {
+		return fmt.Errorf("error removing kustomization CRD manifests: %v", err)
+	}
+
+	scaffolder := scaffolds.NewAPIScaffolder(p.config, *p.resource, p.chart, p.crdOptions, p.rbacOptions,
+		p.watchOptions, p.force)
+	scaffolder.InjectFS(fs)
+	return scaffolder.Scaffold()
+}
+
+func (p *createAPISubcommand) PostScaffold() error {
+	// Only Go types and controllers need new dependencies and generated code
+	if p.resource.Path == "" {
+		return nil
+	}
+
+	if err := util.RunCmd("Update dependencies", "go", "mod", "tidy"); err != nil {
+		return err
+	}
+	if p.runMake {
+		if err := util.RunCmd("Running make", "make", "generate"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	// HelmChartsDir is the relative directory within a hybrid project where
+	// Helm charts are stored.
+	HelmChartsDir string = "helm-charts"
+)
+
+// Options is used to configure how a Helm chart is loaded for scaffolding.
+//
+// Chart can be one of:
+//   - a path to a chart directory or a packaged chart archive on the local filesystem
+//   - a chart reference in a configured repository, e.g. "stable/memcached"
+//   - the URL of a packaged chart archive, e.g. "https://example.com/charts/memcached-0.1.0.tgz"
+//   - the reference of a chart in an OCI registry, e.g. "oci://example.com/charts/memcached"
+//   - a chart name, if Repo is set
+//
+// If Version is set, it is the version of the chart to fetch. Otherwise the
+// latest version is fetched.
+type Options struct {
+	Chart   string
+	Repo    string
+	Version string
+}
+
+// LoadChart loads the chart described by opts, fetching it first if it is not
+// on the local filesystem, and validates its metadata.
+func LoadChart(opts Options) (*chart.Chart, error) {
+	chartPath := opts.Chart
+	if isRemote(opts) {
+		tmpDir, err := ioutil.TempDir("", "hybrid-helm-chart")
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to remove temporary directory %q: %v\n", tmpDir, err)
+			}
+		}()
+
+		chartPath, err = fetchChart(tmpDir, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch chart %q: %v", opts.Chart, err)
+		}
+	}
+
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart %q: %v", opts.Chart, err)
+	}
+	if err := chrt.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid chart %q: %v", opts.Chart, err)
+	}
+	if opts.Version != "" && chrt.Metadata.Version != opts.Version {
+		return nil, fmt.Errorf("chart %q has version %q, expected %q", opts.Chart, chrt.Metadata.Version, opts.Version)
+	}
+	return chrt, nil
+}
+
+// isRemote returns true if the chart described by opts must be fetched.
+func isRemote(opts Options) bool {
+	if opts.Repo != "" || strings.Contains(opts.Chart, "://") {
+		return true
+	}
+	_, err := os.Stat(opts.Chart)
+	return os.IsNotExist(err)
+}
+
+// fetchChart downloads the chart described by opts into destDir, using the
+// Helm repository and registry configuration of the environment, and returns
+// the path to the downloaded chart archive.
+func fetchChart(destDir string, opts Options) (string, error) {
+	settings := cli.New()
+	getters := getter.All(settings)
+
+	if isOCI(opts.Chart) {
+		return pullOCIChart(destDir, opts.Chart, opts.Version)
+	}
+
+	c := downloader.ChartDownloader{
+		Out:              os.Stderr,
+		Verify:           downloader.VerifyNever,
+		Getters:          getters,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+
+	chartRef := opts.Chart
+	if opts.Repo != "" {
+		chartURL, err := repo.FindChartInRepoURL(opts.Repo, opts.Chart, opts.Version, "", "", "", getters)
+		if err != nil {
+			return "", err
+		}
+		chartRef = chartURL
+	}
+
+	chartArchive, _, err := c.DownloadTo(chartRef, opts.Version, destDir)
+	if err != nil {
+		return "", err
+	}
+	return chartArchive, nil
+}
+
+// ChartPath returns the path, relative to the project root, where chrt is
+// stored once it has been written by WriteChart.
+func ChartPath(chrt *chart.Chart) string {
+	return filepath.Join(HelmChartsDir, chrt.Name())
+}
+
+// WriteChart writes every raw file of chrt into ChartPath(chrt) using fs. The
+// raw files are used instead of the parsed chart so that the copy matches the
+// original chart byte for byte, including any vendored subchart archives.
+// An existing chart directory is removed first, so that the files that are not
+// part of chrt, e.g. templates removed in a newer chart version, are not kept.
+func WriteChart(fs afero.Fs, chrt *chart.Chart) error {
+	chartDir := ChartPath(chrt)
+	if err := fs.RemoveAll(chartDir); err != nil {
+		return fmt.Errorf("failed to remove existing chart directory %q: %v", chartDir, err)
+	}
+	for _, f := range chrt.Raw {
+		path := filepath.Join(chartDir, filepath.FromSlash(f.Name))
+		if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %q: %v", path, err)
+		}
+		if err := afero.WriteFile(fs, path, f.Data, 0644); err != nil {
+			return fmt.Errorf("failed to write %q: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// LoadChartDir loads the chart in the directory dir of fs, e.g. a chart written by WriteChart.
+// Unlike the loader of Helm, it does not skip the files listed in the .helmignore file of the chart.
+func LoadChartDir(fs afero.Fs, dir string) (*chart.Chart, error) {
+	var files []*loader.BufferedFile
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, &loader.BufferedFile{Name: filepath.ToSlash(name), Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return loader.LoadFiles(files)
+}
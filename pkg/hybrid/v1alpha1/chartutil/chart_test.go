@@ -0,0 +1,329 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartutil
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	auth "github.com/deislabs/oras/pkg/auth/docker"
+	"github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	"github.com/docker/distribution/configuration"
+	"github.com/docker/distribution/registry/handlers"
+	_ "github.com/docker/distribution/registry/storage/driver/inmemory"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// TestMain points the Helm configuration to a temporary directory, so that the
+// tests neither read nor write the configuration of the user.
+func TestMain(m *testing.M) {
+	helmHome, err := ioutil.TempDir("", "chartutil-test")
+	if err != nil {
+		panic(err)
+	}
+	for env, dir := range map[string]string{
+		"HELM_CACHE_HOME":  "cache",
+		"HELM_CONFIG_HOME": "config",
+		"HELM_DATA_HOME":   "data",
+	} {
+		if err := os.Setenv(env, filepath.Join(helmHome, dir)); err != nil {
+			panic(err)
+		}
+	}
+
+	code := m.Run()
+	os.RemoveAll(helmHome)
+	os.Exit(code)
+}
+
+// newTestChart returns a chart with a single template, at version.
+func newTestChart(name, version string) *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: name, Version: version},
+		Values:   map[string]interface{}{"replicaCount": 1},
+		Templates: []*chart.File{{
+			Name: "templates/configmap.yaml",
+			Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n"),
+		}},
+	}
+}
+
+// newChartRepo serves a chart repository with an index.yaml and the archives of charts, as a chart
+// museum does, and returns its URL.
+func newChartRepo(t *testing.T, charts ...*chart.Chart) string {
+	dir, err := ioutil.TempDir("", "chartutil-repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for _, c := range charts {
+		if _, err := helmchartutil.Save(c, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(srv.Close)
+
+	index, err := repo.IndexDirectory(dir, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index.SortEntries()
+	if err := index.WriteFile(filepath.Join(dir, "index.yaml"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return srv.URL
+}
+
+// addChartRepo adds the repository at url to the Helm configuration as name, as "helm repo add" does.
+func addChartRepo(t *testing.T, name, url string) {
+	settings := cli.New()
+	repoFile := repo.NewFile()
+	entry := &repo.Entry{Name: name, URL: url}
+	repoFile.Update(entry)
+	if err := os.MkdirAll(filepath.Dir(settings.RepositoryConfig), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := repoFile.WriteFile(settings.RepositoryConfig, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := repo.NewChartRepository(entry, getter.All(settings))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.CachePath = settings.RepositoryCache
+	if _, err := r.DownloadIndexFile(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRegistry serves an in-memory OCI registry on localhost, where charts are pushed as Helm does,
+// and returns its host.
+func newRegistry(t *testing.T, charts ...*chart.Chart) string {
+	config := &configuration.Configuration{}
+	config.Storage = configuration.Storage{"inmemory": configuration.Parameters{}}
+	config.Log.Level = "error"
+	srv := httptest.NewServer(handlers.NewApp(context.Background(), config))
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+
+	dir, err := ioutil.TempDir("", "chartutil-registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	client, err := auth.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver, err := client.Resolver(context.Background(), http.DefaultClient, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range charts {
+		archive, err := helmchartutil.Save(c, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadFile(archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		store := content.NewMemoryStore()
+		configDesc := store.Add("", helmChartConfigMediaType, []byte("{}"))
+		layer := store.Add("", helmChartContentLayerMediaType, data)
+		ref := host + "/charts/" + c.Name() + ":" + c.Metadata.Version
+		if _, err := oras.Push(context.Background(), resolver, ref, store, []ocispec.Descriptor{layer},
+			oras.WithConfig(configDesc), oras.WithNameValidation(nil)); err != nil {
+			t.Fatalf("failed to push %s: %v", ref, err)
+		}
+	}
+	return host
+}
+
+func TestLoadChart(t *testing.T) {
+	repoURL := newChartRepo(t, newTestChart("memcached", "0.1.0"), newTestChart("memcached", "0.2.0"))
+	addChartRepo(t, "test", repoURL)
+	registry := newRegistry(t, newTestChart("memcached", "0.1.0"))
+
+	localDir, err := ioutil.TempDir("", "chartutil-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(localDir)
+	if err := helmchartutil.SaveDir(newTestChart("memcached", "0.1.0"), localDir); err != nil {
+		t.Fatal(err)
+	}
+	localArchive, err := helmchartutil.Save(newTestChart("memcached", "0.1.0"), localDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		opts        Options
+		wantVersion string
+		wantErr     string
+	}{
+		{
+			name:        "local directory",
+			opts:        Options{Chart: filepath.Join(localDir, "memcached")},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:        "local archive",
+			opts:        Options{Chart: localArchive},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:    "local chart with another version",
+			opts:    Options{Chart: localArchive, Version: "0.2.0"},
+			wantErr: `chart "` + localArchive + `" has version "0.1.0", expected "0.2.0"`,
+		},
+		{
+			name:        "repository URL, latest version",
+			opts:        Options{Chart: "memcached", Repo: repoURL},
+			wantVersion: "0.2.0",
+		},
+		{
+			name:        "repository URL, specific version",
+			opts:        Options{Chart: "memcached", Repo: repoURL, Version: "0.1.0"},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:    "repository URL, unknown chart",
+			opts:    Options{Chart: "redis", Repo: repoURL},
+			wantErr: `failed to fetch chart "redis"`,
+		},
+		{
+			name:    "repository URL, unknown version",
+			opts:    Options{Chart: "memcached", Repo: repoURL, Version: "0.3.0"},
+			wantErr: `failed to fetch chart "memcached"`,
+		},
+		{
+			name:        "configured repository",
+			opts:        Options{Chart: "test/memcached"},
+			wantVersion: "0.2.0",
+		},
+		{
+			name:        "configured repository, specific version",
+			opts:        Options{Chart: "test/memcached", Version: "0.1.0"},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:        "archive URL",
+			opts:        Options{Chart: repoURL + "/memcached-0.1.0.tgz"},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:        "OCI registry",
+			opts:        Options{Chart: "oci://" + registry + "/charts/memcached", Version: "0.1.0"},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:        "OCI registry, tagged reference",
+			opts:        Options{Chart: "oci://" + registry + "/charts/memcached:0.1.0"},
+			wantVersion: "0.1.0",
+		},
+		{
+			name:    "OCI registry, no version",
+			opts:    Options{Chart: "oci://" + registry + "/charts/memcached"},
+			wantErr: "a chart version is required to pull a chart from an OCI registry",
+		},
+		{
+			name:    "OCI registry, unknown version",
+			opts:    Options{Chart: "oci://" + registry + "/charts/memcached", Version: "0.2.0"},
+			wantErr: `failed to fetch chart "oci://` + registry + `/charts/memcached"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chrt, err := LoadChart(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if chrt.Name() != "memcached" || chrt.Metadata.Version != tt.wantVersion {
+				t.Errorf("expected chart memcached-%s, got %s-%s", tt.wantVersion, chrt.Name(), chrt.Metadata.Version)
+			}
+			if len(chrt.Raw) == 0 {
+				t.Errorf("expected the raw files of the chart to be loaded")
+			}
+		})
+	}
+}
+
+func TestLoadChartDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"Chart.yaml":                       "apiVersion: v2\nname: memcached\nversion: 0.1.0\n",
+		"values.yaml":                      "replicaCount: 1\n",
+		"templates/configmap.yaml":         "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"charts/common/Chart.yaml":         "apiVersion: v2\nname: common\nversion: 1.0.0\n",
+		"charts/common/templates/cm.yaml":  "apiVersion: v1\nkind: ConfigMap\n",
+		"../redis/Chart.yaml":              "apiVersion: v2\nname: redis\nversion: 0.1.0\n",
+		"../invalid/templates/secret.yaml": "apiVersion: v1\nkind: Secret\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(HelmChartsDir, "memcached", filepath.FromSlash(name))
+		if err := afero.WriteFile(fs, path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	chrt, err := LoadChartDir(fs, filepath.Join(HelmChartsDir, "memcached"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if chrt.Name() != "memcached" || chrt.Metadata.Version != "0.1.0" {
+		t.Errorf("expected chart memcached-0.1.0, got %s-%s", chrt.Name(), chrt.Metadata.Version)
+	}
+	if len(chrt.Templates) != 1 || chrt.Templates[0].Name != "templates/configmap.yaml" {
+		t.Errorf("expected the template of the chart, got %+v", chrt.Templates)
+	}
+	if deps := chrt.Dependencies(); len(deps) != 1 || deps[0].Name() != "common" {
+		t.Errorf("expected the common subchart, got %+v", deps)
+	}
+	if len(chrt.Raw) != 5 {
+		t.Errorf("expected the 5 raw files of the chart and its subchart, got %d", len(chrt.Raw))
+	}
+
+	for _, dir := range []string{"invalid", "missing"} {
+		if _, err := LoadChartDir(fs, filepath.Join(HelmChartsDir, dir)); err == nil {
+			t.Errorf("expected an error loading the %s chart", dir)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+
+	auth "github.com/deislabs/oras/pkg/auth/docker"
+	"github.com/deislabs/oras/pkg/content"
+	"github.com/deislabs/oras/pkg/oras"
+	"helm.sh/helm/v3/pkg/cli"
+)
+
+const (
+	ociPrefix = "oci://"
+
+	// helmChartConfigMediaType is the media type of the config of a Helm chart manifest.
+	helmChartConfigMediaType = "application/vnd.cncf.helm.config.v1+json"
+	// helmChartContentLayerMediaType is the media type of the chart layer pushed by Helm 3.8 and later.
+	helmChartContentLayerMediaType = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	// legacyHelmChartContentLayerMediaType is the media type of the chart layer pushed by
+	// the experimental OCI support of earlier Helm versions.
+	legacyHelmChartContentLayerMediaType = "application/tar+gzip"
+)
+
+// isOCI returns true if ref is the reference of a chart in an OCI registry.
+func isOCI(ref string) bool {
+	return strings.HasPrefix(ref, ociPrefix)
+}
+
+// pullOCIChart pulls the chart referenced by ref from an OCI registry into
+// destDir, using the registry credentials of the Helm configuration, and
+// returns the path to the pulled chart archive. The chart version is used as
+// the tag of the reference, it is required unless ref is already tagged.
+func pullOCIChart(destDir, ref, version string) (string, error) {
+	name := strings.TrimPrefix(ref, ociPrefix)
+	if version != "" {
+		name = fmt.Sprintf("%s:%s", name, version)
+	} else if !strings.Contains(path.Base(name), ":") {
+		return "", errors.New("a chart version is required to pull a chart from an OCI registry")
+	}
+
+	settings := cli.New()
+	client, err := auth.NewClient(settings.RegistryConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to load registry credentials: %v", err)
+	}
+	ctx := context.Background()
+	resolver, err := client.Resolver(ctx, http.DefaultClient, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to create registry resolver: %v", err)
+	}
+
+	store := content.NewMemoryStore()
+	_, layers, err := oras.Pull(ctx, resolver, name, store,
+		oras.WithPullEmptyNameAllowed(),
+		oras.WithAllowedMediaTypes([]string{
+			helmChartConfigMediaType,
+			helmChartContentLayerMediaType,
+			legacyHelmChartContentLayerMediaType,
+		}))
+	if err != nil {
+		return "", err
+	}
+
+	for _, layer := range layers {
+		if layer.MediaType != helmChartContentLayerMediaType && layer.MediaType != legacyHelmChartContentLayerMediaType {
+			continue
+		}
+		_, data, ok := store.Get(layer)
+		if !ok {
+			return "", fmt.Errorf("failed to find the chart layer %s of %s", layer.Digest, name)
+		}
+		chartArchive := filepath.Join(destDir, strings.Replace(path.Base(name), ":", "-", 1)+".tgz")
+		if err := ioutil.WriteFile(chartArchive, data, 0644); err != nil {
+			return "", err
+		}
+		return chartArchive, nil
+	}
+	return "", fmt.Errorf("%s does not contain a chart layer", name)
+}
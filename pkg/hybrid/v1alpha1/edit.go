@@ -0,0 +1,127 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin/util"
+)
+
+type editSubcommand struct {
+	config config.Config
+
+	// For help text
+	commandName string
+
+	// flags is used to only apply the options that are set
+	flags *pflag.FlagSet
+
+	multigroup      bool
+	componentConfig bool
+
+	// boilerplate options
+	license string
+	owner   string
+
+	// componentConfigChanged is true if the edit switches the component config mode
+	componentConfigChanged bool
+}
+
+var _ plugin.EditSubcommand = &editSubcommand{}
+
+// UpdateMetadata defines plugin context
+func (p *editSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *plugin.SubcommandMetadata) {
+	subcmdMeta.Description = `Edit the layout of an existing project, only the options that are set are changed:
+	- with --multigroup, the Go types and controllers of new APIs are created under "apis/<group>"
+	  and "controllers/<group>", existing ones are not moved
+	- with --component-config, the manager is configured by "config/manager/controller_manager_config.yaml"
+	  instead of command-line flags, the flags and the loading of the config file are replaced in "main.go",
+	  the rest of it is kept
+	- with --license or --owner, "hack/boilerplate.go.txt" is scaffolded again, existing files keep their header
+
+The changes to the project layout are recorded in the "PROJECT" file.
+`
+	subcmdMeta.Examples = fmt.Sprintf(`  # Enable the multigroup layout
+	$ %[1]s edit --multigroup
+
+	# Configure the manager using a ProjectConfig file
+	$ %[1]s edit --component-config
+
+	# Change the owner in the copyright header of new files
+	$ %[1]s edit --owner "Your Name"
+`, cliMeta.CommandName)
+
+	p.commandName = cliMeta.CommandName
+}
+
+// BindFlags binds the flags used to edit the project
+func (p *editSubcommand) BindFlags(fs *pflag.FlagSet) {
+	fs.SortFlags = false
+
+	fs.BoolVar(&p.multigroup, "multigroup", false, "enable or disable multigroup layout")
+	fs.BoolVar(&p.componentConfig, "component-config", false,
+		"enable or disable configuring the manager using a versioned ComponentConfig file")
+
+	// boilerplate args
+	fs.StringVar(&p.license, "license", "",
+		"license to use to boilerplate, may be one of 'apache2', 'none'")
+	fs.StringVar(&p.owner, "owner", "", "owner to add to the copyright")
+
+	p.flags = fs
+}
+
+func (p *editSubcommand) InjectConfig(c config.Config) error {
+	p.config = c
+
+	if !p.flags.Changed("multigroup") && !p.flags.Changed("component-config") &&
+		!p.flags.Changed("license") && !p.flags.Changed("owner") {
+		return fmt.Errorf("%s edit requires at least one of --multigroup, --component-config, "+
+			"--license and --owner to be set", p.commandName)
+	}
+
+	// Keep the current layout for the options that are not set
+	if !p.flags.Changed("multigroup") {
+		p.multigroup = p.config.IsMultiGroup()
+	}
+	if !p.flags.Changed("component-config") {
+		p.componentConfig = p.config.IsComponentConfig()
+	}
+	p.componentConfigChanged = p.componentConfig != p.config.IsComponentConfig()
+
+	return nil
+}
+
+func (p *editSubcommand) Scaffold(fs machinery.Filesystem) error {
+	scaffolder := scaffolds.NewEditScaffolder(p.config, p.multigroup, p.componentConfig, p.license, p.owner)
+	scaffolder.InjectFS(fs)
+	return scaffolder.Scaffold()
+}
+
+func (p *editSubcommand) PostScaffold() error {
+	// The ProjectConfig type is registered with the scheme of the manager, which requires its DeepCopy methods
+	if p.componentConfigChanged && p.componentConfig {
+		if err := util.RunCmd("Running make", "make", "generate"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
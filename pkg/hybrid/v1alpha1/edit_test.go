@@ -0,0 +1,153 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/afero"
+	sdkutil "github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/util"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// initProject returns a project scaffolded by init on an in-memory filesystem, and its config.
+func initProject(t *testing.T, componentConfig bool) (config.Config, machinery.Filesystem) {
+	c := cfgv3.New()
+	checkErr(t, c.SetRepository("example.com/memcached-operator"), "")
+	checkErr(t, c.SetDomain("example.com"), "")
+	checkErr(t, c.SetProjectName("memcached-operator"), "")
+	if componentConfig {
+		checkErr(t, c.SetComponentConfig(), "")
+	}
+
+	fs := machinery.Filesystem{FS: afero.NewMemMapFs()}
+	checkErr(t, (&initSubcommand{config: c}).Scaffold(fs), "")
+	return c, fs
+}
+
+// readFiles returns the content of the files in fs, keyed by their slash separated path.
+func readFiles(t *testing.T, fs afero.Fs) map[string]string {
+	files := map[string]string{}
+	err := afero.Walk(fs, "", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		b, err := afero.ReadFile(fs, path)
+		files[filepath.ToSlash(path)] = string(b)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// editComponentConfig runs edit --component-config with componentConfig on the project.
+func editComponentConfig(c config.Config, fs machinery.Filesystem, componentConfig bool) error {
+	p := &editSubcommand{
+		config:                 c,
+		componentConfig:        componentConfig,
+		componentConfigChanged: componentConfig != c.IsComponentConfig(),
+	}
+	return p.Scaffold(fs)
+}
+
+func TestEditComponentConfigRoundTrip(t *testing.T) {
+	// The files edited by the switch, the other ones are scaffolded but left in place
+	editedFiles := []string{
+		"main.go",
+		"config/manager/manager.yaml",
+		"config/default/manager_auth_proxy_patch.yaml",
+		"config/default/kustomization.yaml",
+	}
+	for _, componentConfig := range []bool{false, true} {
+		name := "flags"
+		if componentConfig {
+			name = "component config"
+		}
+		t.Run(name, func(t *testing.T) {
+			c, fs := initProject(t, componentConfig)
+			initFiles := readFiles(t, fs.FS)
+
+			checkErr(t, editComponentConfig(c, fs, !componentConfig), "")
+			if c.IsComponentConfig() == componentConfig {
+				t.Fatalf("expected component config %t after the edit", !componentConfig)
+			}
+			otherModeFiles := readFiles(t, fs.FS)
+			for _, path := range editedFiles {
+				if otherModeFiles[path] == initFiles[path] {
+					t.Errorf("expected %s to be edited", path)
+				}
+			}
+
+			checkErr(t, editComponentConfig(c, fs, componentConfig), "")
+			if c.IsComponentConfig() != componentConfig {
+				t.Fatalf("expected component config %t after the edit back", componentConfig)
+			}
+			files := readFiles(t, fs.FS)
+			for _, path := range editedFiles {
+				if files[path] != initFiles[path] {
+					t.Errorf("expected %s as scaffolded by init:\n%s\ngot:\n%s", path, initFiles[path], files[path])
+				}
+			}
+		})
+	}
+}
+
+func TestEditComponentConfigUnchangedOnError(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		old     string
+		new     string
+		wantErr string
+	}{
+		{
+			name:    "main.go edited",
+			path:    "main.go",
+			old:     `setupHelmReconcilers(mgr, watchesFile, "")`,
+			new:     `setupHelmReconcilers(mgr, watchesFile, "helm-charts")`,
+			wantErr: "error updating main.go, it must configure the manager as scaffolded by init",
+		},
+		{
+			name:    "manager manifest edited",
+			path:    "config/default/kustomization.yaml",
+			old:     "#- manager_config_patch.yaml",
+			new:     "",
+			wantErr: "error updating manager manifests",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, fs := initProject(t, false)
+			err := sdkutil.ReplaceInFile(fs.FS, filepath.FromSlash(tt.path), tt.old, tt.new)
+			checkErr(t, err, "")
+			files := readFiles(t, fs.FS)
+
+			checkErr(t, editComponentConfig(c, fs, true), tt.wantErr)
+			if c.IsComponentConfig() {
+				t.Error("expected the component config to be left disabled")
+			}
+			if got := readFiles(t, fs.FS); !reflect.DeepEqual(got, files) {
+				t.Error("expected the project to be left unchanged")
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/pflag"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+const (
+	rbacDiscoveryFlag     = "rbac-discovery"
+	rbacResourcesFileFlag = "rbac-resources-file"
+)
+
+// bindRBACFlags binds the flags configuring how the RBAC rules of the charts are computed to options,
+// shared by the subcommands and commands that compute them
+func bindRBACFlags(fs *pflag.FlagSet, options *scaffolds.RBACOptions) {
+	fs.StringVar(&options.Discovery, rbacDiscoveryFlag, scaffolds.RBACDiscoveryAuto,
+		fmt.Sprintf("how to discover the resources rendered by the charts to compute their RBAC rules, "+
+			"one of %q (use the cluster in the kubeconfig if reachable, built-in Kubernetes resources otherwise), "+
+			"%q or %q (built-in Kubernetes resources only, no cluster access needed)",
+			scaffolds.RBACDiscoveryAuto, scaffolds.RBACDiscoveryCluster, scaffolds.RBACDiscoveryStatic))
+	fs.StringVar(&options.ResourcesFile, rbacResourcesFileFlag, "",
+		"YAML file with a list of additional resource mappings (apiVersion, kind, resource, namespaced) "+
+			"used to compute RBAC rules for kinds that can not be discovered")
+}
+
+// validateRBACOptions checks the values of the flags bound by bindRBACFlags
+func validateRBACOptions(options scaffolds.RBACOptions) error {
+	switch options.Discovery {
+	case scaffolds.RBACDiscoveryAuto, scaffolds.RBACDiscoveryCluster, scaffolds.RBACDiscoveryStatic:
+	default:
+		return fmt.Errorf("invalid value %q for --%s, must be one of %q, %q or %q", options.Discovery,
+			rbacDiscoveryFlag, scaffolds.RBACDiscoveryAuto, scaffolds.RBACDiscoveryCluster, scaffolds.RBACDiscoveryStatic)
+	}
+	if options.ResourcesFile != "" {
+		if _, err := os.Stat(options.ResourcesFile); err != nil {
+			return fmt.Errorf("invalid value for --%s: %v", rbacResourcesFileFlag, err)
+		}
+	}
+	return nil
+}
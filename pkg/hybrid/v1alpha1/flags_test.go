@@ -0,0 +1,70 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+func TestRBACFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    scaffolds.RBACOptions
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: scaffolds.RBACOptions{Discovery: scaffolds.RBACDiscoveryAuto},
+		},
+		{
+			name: "static discovery",
+			args: []string{"--rbac-discovery=static"},
+			want: scaffolds.RBACOptions{Discovery: scaffolds.RBACDiscoveryStatic},
+		},
+		{
+			name: "resources file",
+			args: []string{"--rbac-discovery=cluster", "--rbac-resources-file=flags_test.go"},
+			want: scaffolds.RBACOptions{Discovery: scaffolds.RBACDiscoveryCluster, ResourcesFile: "flags_test.go"},
+		},
+		{
+			name:    "unknown discovery",
+			args:    []string{"--rbac-discovery=offline"},
+			wantErr: `invalid value "offline" for --rbac-discovery, must be one of "auto", "cluster" or "static"`,
+		},
+		{
+			name:    "missing resources file",
+			args:    []string{"--rbac-resources-file=missing.yaml"},
+			wantErr: "invalid value for --rbac-resources-file: stat missing.yaml: no such file or directory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := scaffolds.RBACOptions{}
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			bindRBACFlags(fs, &options)
+			checkErr(t, fs.Parse(tt.args), "")
+
+			checkErr(t, validateRBACOptions(options), tt.wantErr)
+			if tt.wantErr == "" && options != tt.want {
+				t.Errorf("expected options %+v, got %+v", tt.want, options)
+			}
+		})
+	}
+}
@@ -16,12 +16,20 @@ package v1alpha1
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/pflag"
 	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
 
 	sdkutil "github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/util"
+	"golang.org/x/mod/module"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/kubebuilder/v3/pkg/config"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
@@ -29,19 +37,32 @@ import (
 	"sigs.k8s.io/kubebuilder/v3/pkg/plugins/golang"
 )
 
-// TODO: Implement the apiSubcommand.
 type initSubcommand struct {
 	config config.Config
 
 	// For help text
 	commandName string
 
+	// project options, only set if not bound by a preceding plugin
+	domain          string
+	name            string
+	componentConfig bool
+
 	// boilerplate options
 	license string
 	owner   string
 
 	// go config options
 	repo string
+
+	// skipGoVersionCheck disables the check of the installed Go version
+	skipGoVersionCheck bool
+
+	// skipDeps disables fetching the dependencies, so that init works without network access
+	skipDeps bool
+
+	// watchNamespaces are the namespaces watched by the manager, all namespaces if empty
+	watchNamespaces []string
 }
 
 var _ plugin.InitSubcommand = &initSubcommand{}
@@ -52,8 +73,12 @@ func (p *initSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *
 	- a "go.mod" with project dependencies
 	- a "PROJECT" file that stores project configuration
 	- a "Makefile" with several useful make targets for the project
+	- a "Dockerfile" that builds the manager image, with the watches file and the charts
 	- several YAML files for project deployment under the "config" directory
 	- a "main.go" file that creates the manager that will run the project controllers
+	- with --component-config, a "ProjectConfig" type under "api/config" that configures the manager
+	- with --watch-namespaces, a manager that only watches these namespaces, with a "Role" and a "RoleBinding"
+	  in each of them, and the rules of cluster-scoped resources in a "ClusterRole"
   `
 	subcmdMeta.Examples = fmt.Sprintf(`  # Initialize a new project with your domain and name in copyright
 	$ %[1]s init --plugins=%[2]s --domain=example.com --owner "Your Name"
@@ -65,11 +90,32 @@ func (p *initSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *
 	p.commandName = cliMeta.CommandName
 }
 
-// TODO: bind the same set of flags to the apiSubcommand
 func (p *initSubcommand) BindFlags(fs *pflag.FlagSet) {
 	fs.SortFlags = false
 
+	fs.BoolVar(&p.skipGoVersionCheck, "skip-go-version-check", false,
+		"if specified, skip checking the Go version")
+	fs.BoolVar(&p.skipDeps, "skip-deps", false, "if specified, do not download the dependencies, "+
+		"go.mod pins the direct dependencies and `go mod tidy` must be run before building the project")
+
 	// project args
+	// The domain and project name are bound by the kustomize plugin when it precedes
+	// this plugin in a bundle, they are only bound here if this plugin is used on its own.
+	if fs.Lookup("domain") == nil {
+		fs.StringVar(&p.domain, "domain", "my.domain", "domain for groups")
+	}
+	if fs.Lookup("project-name") == nil {
+		fs.StringVar(&p.name, "project-name", "", "name of this project, "+
+			"defaults to the name of the current working directory.")
+	}
+	if fs.Lookup("component-config") == nil {
+		fs.BoolVar(&p.componentConfig, "component-config", false,
+			"create a versioned ComponentConfig file, may be 'true' or 'false'")
+	}
+	fs.StringSliceVar(&p.watchNamespaces, "watch-namespaces", nil, "comma separated list of the namespaces "+
+		"watched by the manager, set in its WATCH_NAMESPACE environment variable, defaults to all namespaces. "+
+		"If set, the manager roles are created and bound in each of these namespaces instead of cluster-wide, "+
+		"and the rules of cluster-scoped resources are granted by a separate cluster role")
 	fs.StringVar(&p.repo, "repo", "", "name to use for go module (e.g., github.com/user/repo), "+
 		"defaults to the go package of the current working directory.")
 
@@ -77,12 +123,41 @@ func (p *initSubcommand) BindFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&p.license, "license", "apache2",
 		"license to use to boilerplate, may be one of 'apache2', 'none'")
 	fs.StringVar(&p.owner, "owner", "", "owner to add to the copyright")
-
 }
 
 func (p *initSubcommand) InjectConfig(c config.Config) error {
 	p.config = c
 
+	if p.domain != "" {
+		if err := p.config.SetDomain(p.domain); err != nil {
+			return err
+		}
+	}
+
+	if p.componentConfig {
+		if err := p.config.SetComponentConfig(); err != nil {
+			return err
+		}
+	}
+
+	if p.config.GetProjectName() == "" {
+		// Assign a default project name
+		if p.name == "" {
+			dir, err := os.Getwd()
+			if err != nil {
+				return fmt.Errorf("error getting current directory: %v", err)
+			}
+			p.name = strings.ToLower(filepath.Base(dir))
+		}
+		// The project name is used as namespace and name prefix in the kustomize manifests
+		if errs := validation.IsDNS1123Label(p.name); len(errs) != 0 {
+			return fmt.Errorf("project name (%s) is invalid: %s", p.name, strings.Join(errs, ", "))
+		}
+		if err := p.config.SetProjectName(p.name); err != nil {
+			return err
+		}
+	}
+
 	// Try to guess repository if flag is not set
 	if p.repo == "" {
 		repoPath, err := golang.FindCurrentRepo()
@@ -98,91 +173,205 @@ func (p *initSubcommand) InjectConfig(c config.Config) error {
 	return nil
 }
 
-// TODO: Pre-scaffold check to verify if the right Go version and directory is used
-// needs to be added from Kubebuilder.
-func (p *initSubcommand) Scaffold(fs machinery.Filesystem) error {
-	// TODO: add customizations to config files, as done in helm operator plugin
-	// if err := addInitCustomizations(p.config.GetProjectName()); err != nil {
-	// 	return fmt.Errorf("error updating init manifests: %s", err)
-	// }
+// PreScaffold verifies that the project can be initialized, before any file is written
+func (p *initSubcommand) PreScaffold(fs machinery.Filesystem) error {
+	if !p.skipGoVersionCheck {
+		if err := checkGoVersion(scaffolds.GoVersion); err != nil {
+			return fmt.Errorf("%v, you can skip this check using the --skip-go-version-check flag", err)
+		}
+	}
+
+	// Check that the current directory has no files which would conflict with the scaffolded ones
+	if err := checkDir(fs.FS); err != nil {
+		return err
+	}
+
+	if err := module.CheckImportPath(p.config.GetRepository()); err != nil {
+		return fmt.Errorf("invalid value %q for --repo, must be a valid Go module path: %v",
+			p.config.GetRepository(), err)
+	}
+
+	for _, ns := range p.watchNamespaces {
+		if errs := validation.IsDNS1123Label(ns); len(errs) != 0 {
+			return fmt.Errorf("invalid value %q for --watch-namespaces, it must be a DNS-1123 label: %s",
+				ns, strings.Join(errs, ", "))
+		}
+	}
+
+	// The domain is the suffix of the API groups of the project
+	if errs := validation.IsDNS1123Subdomain(p.config.GetDomain()); len(errs) != 0 {
+		return fmt.Errorf("domain (%s) is invalid, it must be a DNS-1123 subdomain: %s",
+			p.config.GetDomain(), strings.Join(errs, ", "))
+	}
+
+	return nil
+}
 
-	scaffolder := scaffolds.NewInitScaffolder(p.config, p.license, p.owner)
+func (p *initSubcommand) Scaffold(fs machinery.Filesystem) error {
+	scaffolder := scaffolds.NewInitScaffolder(p.config, p.license, p.owner, p.watchNamespaces)
 	scaffolder.InjectFS(fs)
-	err := scaffolder.Scaffold()
-	if err != nil {
+	if err := scaffolder.Scaffold(); err != nil {
 		return err
 	}
 
-	// Ensure that we are pinning the controller-runtime version
-	// xref: https://github.com/kubernetes-sigs/kubebuilder/issues/997
-	err = util.RunCmd("Get controller runtime", "go", "get",
-		"sigs.k8s.io/controller-runtime@"+scaffolds.ControllerRuntimeVersion)
+	err := addInitCustomizations(fs.FS, scaffolds.LeaderElectionID(p.config), p.config.IsComponentConfig(),
+		p.watchNamespaces)
 	if err != nil {
-		return err
+		return fmt.Errorf("error updating init manifests: %v", err)
 	}
 	return nil
 }
 
 func (p *initSubcommand) PostScaffold() error {
+	if p.skipDeps {
+		fmt.Println("Dependencies were not downloaded, run `go mod tidy` to download them " +
+			"and populate go.sum before building the project.")
+		if p.config.IsComponentConfig() {
+			fmt.Println("Run `make generate` to generate the DeepCopy methods of the ProjectConfig type.")
+		}
+		return nil
+	}
+
 	err := util.RunCmd("Update dependencies", "go", "mod", "tidy")
 	if err != nil {
 		return err
 	}
 
+	// The ProjectConfig type is registered with the scheme of the manager, which requires its DeepCopy methods
+	if p.config.IsComponentConfig() {
+		if err := util.RunCmd("Running make", "make", "generate"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
 // addInitCustomizations will perform the required customizations for this plugin on the common base
-func addInitCustomizations(projectName string) error {
+func addInitCustomizations(fs afero.Fs, leaderElectionID string, componentConfig bool,
+	watchNamespaces []string) error {
 	managerFile := filepath.Join("config", "manager", "manager.yaml")
 
-	// todo: we ought to use afero instead. Replace this methods to insert/update
-	// by https://github.com/kubernetes-sigs/kubebuilder/pull/2119
-
-	// Add leader election arg in config/manager/manager.yaml and in config/default/manager_auth_proxy_patch.yaml
-	err := sdkutil.InsertCode(managerFile,
-		"--leader-elect",
-		fmt.Sprintf("\n        - --leader-election-id=%s", projectName))
-	if err != nil {
-		return err
+	// Add leader election arg in config/manager/manager.yaml and in config/default/manager_auth_proxy_patch.yaml,
+	// the manager is configured by controller_manager_config.yaml instead in component config mode.
+	if !componentConfig {
+		err := sdkutil.InsertCode(fs, managerFile,
+			"--leader-elect",
+			fmt.Sprintf("\n        - --leader-election-id=%s", leaderElectionID))
+		if err != nil {
+			return err
+		}
+		err = sdkutil.InsertCode(fs, filepath.Join("config", "default", "manager_auth_proxy_patch.yaml"),
+			"- \"--leader-elect\"",
+			fmt.Sprintf("\n        - \"--leader-election-id=%s\"", leaderElectionID))
+		if err != nil {
+			return err
+		}
 	}
-	err = sdkutil.InsertCode(filepath.Join("config", "default", "manager_auth_proxy_patch.yaml"),
-		"- \"--leader-elect\"",
-		fmt.Sprintf("\n        - \"--leader-election-id=%s\"", projectName))
-	if err != nil {
-		return err
+
+	// Restrict the namespaces watched by the manager
+	if len(watchNamespaces) != 0 {
+		err := sdkutil.InsertCode(fs, managerFile,
+			"\n        name: manager",
+			fmt.Sprintf("\n        env:\n        - name: WATCH_NAMESPACE\n          value: %q",
+				strings.Join(watchNamespaces, ",")))
+		if err != nil {
+			return err
+		}
 	}
 
 	// Increase the default memory required.
-	err = sdkutil.ReplaceInFile(managerFile, "memory: 30Mi", "memory: 90Mi")
+	err := sdkutil.ReplaceInFile(fs, managerFile, "memory: 30Mi", "memory: 90Mi")
 	if err != nil {
 		return err
 	}
-	err = sdkutil.ReplaceInFile(managerFile, "memory: 20Mi", "memory: 60Mi")
+	err = sdkutil.ReplaceInFile(fs, managerFile, "memory: 20Mi", "memory: 60Mi")
 	if err != nil {
 		return err
 	}
 
-	// Remove the webhook option for the componentConfig since webhooks are not supported by helm
-	err = sdkutil.ReplaceInFile(filepath.Join("config", "manager", "controller_manager_config.yaml"),
-		"webhook:\n  port: 9443", "")
-	if err != nil {
-		return err
-	}
+	return nil
+}
 
-	// Remove the call to the command as manager. Helm has not been exposing this entrypoint
-	// todo: provide the manager entrypoint for helm and then remove it
-	const command = `command:
-        - /manager
-        `
-	err = sdkutil.ReplaceInFile(managerFile, command, "")
+// checkGoVersion returns an error if the installed Go version is older than minVersion, the
+// version in the go.mod of the project.
+func checkGoVersion(minVersion string) error {
+	out, err := exec.Command("go", "version").Output()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to retrieve 'go version': %v", err)
 	}
+	return checkGoVersionOutput(string(out), minVersion)
+}
 
-	if err := sdkutil.UpdateKustomizationsInit(); err != nil {
-		return fmt.Errorf("error updating kustomization.yaml files: %v", err)
+// checkGoVersionOutput returns an error if the Go version in out, the output of `go version`,
+// is older than minVersion.
+func checkGoVersionOutput(out, minVersion string) error {
+	// The output looks like "go version go1.16.3 linux/amd64"
+	fields := strings.Fields(out)
+	if len(fields) < 3 {
+		return fmt.Errorf("found invalid Go version: %q", out)
+	}
+	version, ok := parseGoVersion(strings.TrimPrefix(fields[2], "go"))
+	if !ok {
+		return fmt.Errorf("unable to parse Go version %q", fields[2])
+	}
+	minimum, ok := parseGoVersion(minVersion)
+	if !ok {
+		return fmt.Errorf("unable to parse Go version %q", minVersion)
 	}
 
+	if version[0] < minimum[0] || (version[0] == minimum[0] && version[1] < minimum[1]) {
+		return fmt.Errorf("go version %q is incompatible, the project requires go%s or later", fields[2], minVersion)
+	}
 	return nil
 }
+
+// parseGoVersion returns the major and minor numbers of a Go version such as "1.16.3" or "1.17rc1".
+func parseGoVersion(version string) ([2]int, bool) {
+	m := goVersionRegexp.FindStringSubmatch(version)
+	if m == nil {
+		return [2]int{}, false
+	}
+	major, _ := strconv.Atoi(m[1])
+	minor, _ := strconv.Atoi(m[2])
+	return [2]int{major, minor}, true
+}
+
+var goVersionRegexp = regexp.MustCompile(`^([0-9]+)\.([0-9]+)`)
+
+// checkDir returns an error if the current directory has files which are not allowed.
+// Note that, it is expected that the directory to scaffold the project is cleaned.
+// Otherwise, it might face issues to do the scaffold.
+func checkDir(fs afero.Fs) error {
+	// Files which may exist before the project is initialized
+	allowedFiles := []string{
+		"go.mod",    // user might run `go mod init` instead of providing the `--repo` flag at init
+		"go.sum",    // auto-generated file related to go.mod
+		"LICENSE",   // can be generated when initializing a GitHub project
+		"README.md", // can be generated when initializing a GitHub project
+		"PROJECT",   // written by the CLI, which refuses to initialize a project twice
+	}
+
+	return afero.Walk(fs, ".", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == "." {
+			return nil
+		}
+		// Allow files and directory trees starting with '.', such as .git
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, allowedFile := range allowedFiles {
+			if path == allowedFile {
+				return nil
+			}
+		}
+		return fmt.Errorf("target directory is not empty (only %s, and files and directories with the prefix "+
+			"\".\" are allowed); found existing file %q", strings.Join(allowedFiles, ", "), path)
+	})
+}
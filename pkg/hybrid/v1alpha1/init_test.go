@@ -0,0 +1,343 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	cfgv3 "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+// writeFiles returns an in-memory filesystem with files, keyed by their slash separated path.
+func writeFiles(t *testing.T, files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, filepath.FromSlash(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+// checkFile checks that the file at the slash separated path in fs contains want.
+func checkFile(t *testing.T, fs afero.Fs, path, want string) {
+	b, err := afero.ReadFile(fs, filepath.FromSlash(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != want {
+		t.Errorf("expected %s:\n%s\ngot:\n%s", path, want, b)
+	}
+}
+
+func TestCheckDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{
+			name: "empty directory",
+		},
+		{
+			name:  "allowed files",
+			files: []string{"go.mod", "go.sum", "LICENSE", "README.md", "PROJECT"},
+		},
+		{
+			name:  "hidden files and directories",
+			files: []string{".gitignore", ".git/config", ".github/workflows/ci.yaml"},
+		},
+		{
+			name:    "other file",
+			files:   []string{"go.mod", "main.go"},
+			wantErr: `found existing file "main.go"`,
+		},
+		{
+			name:    "allowed file in a directory",
+			files:   []string{"docs/README.md"},
+			wantErr: `found existing file "docs"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := map[string]string{}
+			for _, file := range tt.files {
+				files[file] = ""
+			}
+			checkErr(t, checkDir(writeFiles(t, files)), tt.wantErr)
+		})
+	}
+}
+
+func TestCheckGoVersionOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		out        string
+		minVersion string
+		wantErr    string
+	}{
+		{
+			name:       "same version",
+			out:        "go version go1.16 linux/amd64\n",
+			minVersion: "1.16",
+		},
+		{
+			name:       "newer patch version",
+			out:        "go version go1.16.3 linux/amd64\n",
+			minVersion: "1.16",
+		},
+		{
+			name:       "newer minor version",
+			out:        "go version go1.17rc1 darwin/arm64\n",
+			minVersion: "1.16",
+		},
+		{
+			name:       "newer major version",
+			out:        "go version go2.0 linux/amd64\n",
+			minVersion: "1.16",
+		},
+		{
+			name:       "older minor version",
+			out:        "go version go1.15.8 linux/amd64\n",
+			minVersion: "1.16",
+			wantErr:    `go version "go1.15.8" is incompatible, the project requires go1.16 or later`,
+		},
+		{
+			name:       "minor versions compared as numbers",
+			out:        "go version go1.9 linux/amd64\n",
+			minVersion: "1.16",
+			wantErr:    `go version "go1.9" is incompatible`,
+		},
+		{
+			name:       "development version",
+			out:        "go version devel +a1b2c3 linux/amd64\n",
+			minVersion: "1.16",
+			wantErr:    `unable to parse Go version "devel"`,
+		},
+		{
+			name:       "invalid output",
+			out:        "go1.16",
+			minVersion: "1.16",
+			wantErr:    `found invalid Go version: "go1.16"`,
+		},
+		{
+			name:       "invalid minimum version",
+			out:        "go version go1.16 linux/amd64\n",
+			minVersion: "one",
+			wantErr:    `unable to parse Go version "one"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkErr(t, checkGoVersionOutput(tt.out, tt.minVersion), tt.wantErr)
+		})
+	}
+}
+
+func TestInitPreScaffold(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           []string
+		repo            string
+		domain          string
+		watchNamespaces []string
+		wantErr         string
+	}{
+		{
+			name:            "valid project",
+			files:           []string{"go.mod", ".git/HEAD"},
+			repo:            "example.com/memcached-operator",
+			domain:          "example.com",
+			watchNamespaces: []string{"foo", "bar"},
+		},
+		{
+			name:    "directory not empty",
+			files:   []string{"Makefile"},
+			repo:    "example.com/memcached-operator",
+			domain:  "example.com",
+			wantErr: `found existing file "Makefile"`,
+		},
+		{
+			name:    "invalid repository",
+			repo:    "example.com/memcached operator",
+			domain:  "example.com",
+			wantErr: `invalid value "example.com/memcached operator" for --repo`,
+		},
+		{
+			name:            "namespace not a DNS-1123 label",
+			repo:            "example.com/memcached-operator",
+			domain:          "example.com",
+			watchNamespaces: []string{"foo", "Bar"},
+			wantErr:         `invalid value "Bar" for --watch-namespaces, it must be a DNS-1123 label`,
+		},
+		{
+			name:            "namespace with a dot",
+			repo:            "example.com/memcached-operator",
+			domain:          "example.com",
+			watchNamespaces: []string{"foo.bar"},
+			wantErr:         `invalid value "foo.bar" for --watch-namespaces`,
+		},
+		{
+			name:    "domain not a DNS-1123 subdomain",
+			repo:    "example.com/memcached-operator",
+			domain:  "Example_com",
+			wantErr: "domain (Example_com) is invalid, it must be a DNS-1123 subdomain",
+		},
+		{
+			name:    "domain with a trailing dot",
+			repo:    "example.com/memcached-operator",
+			domain:  "example.com.",
+			wantErr: "domain (example.com.) is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfgv3.New()
+			checkErr(t, c.SetRepository(tt.repo), "")
+			checkErr(t, c.SetDomain(tt.domain), "")
+
+			files := map[string]string{}
+			for _, file := range tt.files {
+				files[file] = ""
+			}
+			p := &initSubcommand{
+				config:             c,
+				skipGoVersionCheck: true,
+				watchNamespaces:    tt.watchNamespaces,
+			}
+			checkErr(t, p.PreScaffold(machinery.Filesystem{FS: writeFiles(t, files)}), tt.wantErr)
+		})
+	}
+}
+
+const (
+	testManager = `      containers:
+      - command:
+        - /manager
+        args:
+        - --leader-elect
+        image: controller:latest
+        name: manager
+        securityContext:
+          allowPrivilegeEscalation: false
+        resources:
+          limits:
+            cpu: 100m
+            memory: 30Mi
+          requests:
+            cpu: 100m
+            memory: 20Mi
+`
+	testAuthProxyPatch = `      - name: manager
+        args:
+        - "--health-probe-bind-address=:8081"
+        - "--metrics-bind-address=127.0.0.1:8080"
+        - "--leader-elect"
+`
+)
+
+func TestAddInitCustomizations(t *testing.T) {
+	tests := []struct {
+		name               string
+		componentConfig    bool
+		watchNamespaces    []string
+		wantManager        string
+		wantAuthProxyPatch string
+	}{
+		{
+			name: "default",
+			wantManager: `      containers:
+      - command:
+        - /manager
+        args:
+        - --leader-elect
+        - --leader-election-id=memcached-operator
+        image: controller:latest
+        name: manager
+        securityContext:
+          allowPrivilegeEscalation: false
+        resources:
+          limits:
+            cpu: 100m
+            memory: 90Mi
+          requests:
+            cpu: 100m
+            memory: 60Mi
+`,
+			wantAuthProxyPatch: `      - name: manager
+        args:
+        - "--health-probe-bind-address=:8081"
+        - "--metrics-bind-address=127.0.0.1:8080"
+        - "--leader-elect"
+        - "--leader-election-id=memcached-operator"
+`,
+		},
+		{
+			name:            "watched namespaces in component config mode",
+			componentConfig: true,
+			watchNamespaces: []string{"foo", "bar"},
+			wantManager: `      containers:
+      - command:
+        - /manager
+        args:
+        - --leader-elect
+        image: controller:latest
+        name: manager
+        env:
+        - name: WATCH_NAMESPACE
+          value: "foo,bar"
+        securityContext:
+          allowPrivilegeEscalation: false
+        resources:
+          limits:
+            cpu: 100m
+            memory: 90Mi
+          requests:
+            cpu: 100m
+            memory: 60Mi
+`,
+			wantAuthProxyPatch: testAuthProxyPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := writeFiles(t, map[string]string{
+				"config/manager/manager.yaml":                  testManager,
+				"config/default/manager_auth_proxy_patch.yaml": testAuthProxyPatch,
+			})
+			err := addInitCustomizations(fs, "memcached-operator", tt.componentConfig, tt.watchNamespaces)
+			checkErr(t, err, "")
+			checkFile(t, fs, "config/manager/manager.yaml", tt.wantManager)
+			checkFile(t, fs, "config/default/manager_auth_proxy_patch.yaml", tt.wantAuthProxyPatch)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+// Formats of the output of the lint command
+const (
+	lintOutputText = "text"
+	lintOutputJSON = "json"
+)
+
+// NewLintCommand returns a command that cross-checks the files of a hybrid project, commandName is the
+// name of the CLI. Plugins can not add commands to the CLI, it must be added with cli.WithExtraAlphaCommands.
+func NewLintCommand(commandName string) *cobra.Command {
+	var output string
+	rbacOptions := scaffolds.RBACOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "lint",
+		Short: "Check that the files of a hybrid project are consistent",
+		Long: `Check that the files of the project in the current directory are consistent:
+	- every watch of "watches.yaml" has a chart directory, and a CRD in "config/crd/bases"
+	- every chart renders with its default values
+	- "config/rbac/role.yaml", and "config/rbac/cluster_role.yaml" if the project has it, grant the rules
+	  needed to manage the resources rendered by the charts
+	- "main.go" has the scaffold markers used by "create api" and "create webhook"
+
+The command fails if a problem is found, so that it can be run in CI.
+`,
+		Example: fmt.Sprintf(`  # Check the project in the current directory
+  $ %[1]s alpha lint
+
+  # Check the project and print the problems found as JSON
+  $ %[1]s alpha lint --output=json`, commandName),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			switch output {
+			case lintOutputText, lintOutputJSON:
+			default:
+				return fmt.Errorf("invalid value %q for --output, must be one of %q or %q",
+					output, lintOutputText, lintOutputJSON)
+			}
+			if err := validateRBACOptions(rbacOptions); err != nil {
+				return err
+			}
+
+			problems, err := scaffolds.Lint(afero.NewOsFs(), rbacOptions)
+			if err != nil {
+				return err
+			}
+			if err := printLintProblems(os.Stdout, problems, output); err != nil {
+				return err
+			}
+			if len(problems) != 0 {
+				return fmt.Errorf("found %d problem(s)", len(problems))
+			}
+			return nil
+		},
+	}
+
+	fs := cmd.Flags()
+	fs.StringVarP(&output, "output", "o", lintOutputText,
+		fmt.Sprintf("format of the problems found, one of %q or %q", lintOutputText, lintOutputJSON))
+	bindRBACFlags(fs, &rbacOptions)
+
+	return cmd
+}
+
+// printLintProblems writes the problems found by Lint to w in the output format
+func printLintProblems(w io.Writer, problems []scaffolds.LintProblem, output string) error {
+	if output == lintOutputJSON {
+		if problems == nil {
+			problems = []scaffolds.LintProblem{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Problems []scaffolds.LintProblem `json:"problems"`
+		}{problems})
+	}
+
+	for _, p := range problems {
+		fmt.Fprintln(w, p)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintln(w, "No problems found.")
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+func TestPrintLintProblems(t *testing.T) {
+	problems := []scaffolds.LintProblem{
+		{Check: scaffolds.LintCheckCRD, File: "config/crd/bases",
+			Message: "no CRD defines cache.example.com/v1alpha1, Kind=Redis, watched in watches.yaml"},
+		{Check: scaffolds.LintCheckMain, File: "main.go",
+			Message: `missing scaffold marker "//+kubebuilder:scaffold:builder"`},
+	}
+	tests := []struct {
+		name     string
+		problems []scaffolds.LintProblem
+		output   string
+		want     string
+	}{
+		{
+			name:     "text",
+			problems: problems,
+			output:   lintOutputText,
+			want: "config/crd/bases: no CRD defines cache.example.com/v1alpha1, Kind=Redis, watched in " +
+				"watches.yaml [crd]\n" +
+				`main.go: missing scaffold marker "//+kubebuilder:scaffold:builder" [main]` + "\n",
+		},
+		{
+			name:   "text without problems",
+			output: lintOutputText,
+			want:   "No problems found.\n",
+		},
+		{
+			name:     "JSON",
+			problems: problems,
+			output:   lintOutputJSON,
+			want: `{
+  "problems": [
+    {
+      "check": "crd",
+      "file": "config/crd/bases",
+      "message": "no CRD defines cache.example.com/v1alpha1, Kind=Redis, watched in watches.yaml"
+    },
+    {
+      "check": "main",
+      "file": "main.go",
+      "message": "missing scaffold marker \"//+kubebuilder:scaffold:builder\""
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "JSON without problems",
+			output: lintOutputJSON,
+			want:   "{\n  \"problems\": []\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			checkErr(t, printLintProblems(buf, tt.problems, tt.output), "")
+			if buf.String() != tt.want {
+				t.Errorf("expected output:\n%s\ngot:\n%s", tt.want, buf)
+			}
+		})
+	}
+}
@@ -30,15 +30,26 @@ var (
 )
 
 var (
-	_ plugin.Plugin = Plugin{}
-	_ plugin.Init   = Plugin{}
+	_ plugin.Plugin        = Plugin{}
+	_ plugin.Init          = Plugin{}
+	_ plugin.CreateAPI     = Plugin{}
+	_ plugin.CreateWebhook = Plugin{}
+	_ plugin.Edit          = Plugin{}
 )
 
 type Plugin struct {
 	initSubcommand
+	createAPISubcommand
+	createWebhookSubcommand
+	editSubcommand
 }
 
-func (Plugin) Name() string                               { return pluginName }
-func (Plugin) Version() plugin.Version                    { return pluginVersion }
-func (Plugin) SupportedProjectVersions() []config.Version { return supportedProjectVersions }
-func (p Plugin) GetInitSubcommand() plugin.InitSubcommand { return &p.initSubcommand }
+func (Plugin) Name() string                                         { return pluginName }
+func (Plugin) Version() plugin.Version                              { return pluginVersion }
+func (Plugin) SupportedProjectVersions() []config.Version           { return supportedProjectVersions }
+func (p Plugin) GetInitSubcommand() plugin.InitSubcommand           { return &p.initSubcommand }
+func (p Plugin) GetCreateAPISubcommand() plugin.CreateAPISubcommand { return &p.createAPISubcommand }
+func (p Plugin) GetEditSubcommand() plugin.EditSubcommand           { return &p.editSubcommand }
+func (p Plugin) GetCreateWebhookSubcommand() plugin.CreateWebhookSubcommand {
+	return &p.createWebhookSubcommand
+}
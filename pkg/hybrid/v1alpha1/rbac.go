@@ -0,0 +1,161 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+// Formats of the output of the rbac check command
+const (
+	rbacOutputText = "text"
+	rbacOutputJSON = "json"
+)
+
+// NewRBACCommand returns a command that compares the rules of the manager role of a hybrid project
+// with the rules needed by its charts, commandName is the name of the CLI. Plugins can not add
+// commands to the CLI, it must be added with cli.WithExtraAlphaCommands.
+func NewRBACCommand(commandName string) *cobra.Command {
+	rbacOptions := scaffolds.RBACOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "rbac",
+		Short: "Compare the manager role with the rules needed by the charts",
+		Long: `Compare "config/rbac/role.yaml" with the rules needed by the charts of the kinds in "watches.yaml".
+
+The rules of a chart are computed from its default manifests, as when its kind is created. They change
+when the chart is updated, while role.yaml does not. The rules generated for each kind in role.yaml,
+under its "## Rules for" header, can be checked with "rbac check" and generated again with "rbac sync".
+
+In projects initialized with --watch-namespaces, role.yaml has a role in each watched namespace, whose
+rules the commands check and generate again, and the rules of the cluster-scoped resources are in
+"config/rbac/cluster_role.yaml", which the commands check and generate again as well.
+`,
+		Example: fmt.Sprintf(`  # Report the permissions missing from role.yaml, and the ones the charts do not need
+  $ %[1]s alpha rbac check
+
+  # Generate again the rules of the kinds in watches.yaml in role.yaml
+  $ %[1]s alpha rbac sync`, commandName),
+		SilenceUsage: true,
+		PersistentPreRunE: func(*cobra.Command, []string) error {
+			return validateRBACOptions(rbacOptions)
+		},
+	}
+
+	bindRBACFlags(cmd.PersistentFlags(), &rbacOptions)
+
+	cmd.AddCommand(newRBACCheckCommand(&rbacOptions), newRBACSyncCommand(&rbacOptions))
+
+	return cmd
+}
+
+func newRBACCheckCommand(rbacOptions *scaffolds.RBACOptions) *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report the permissions missing from the manager role, and the ones the charts do not need",
+		Long: `Report the permissions needed by the charts that "config/rbac/role.yaml" does not grant, and the
+permissions granted by the rules generated for a kind that no chart needs. The rules generated for
+kinds that are not in the project anymore are reported as not needed.
+
+The command fails if a difference is found, so that it can be run in CI.
+`,
+		SilenceUsage: true,
+		RunE: func(*cobra.Command, []string) error {
+			switch output {
+			case rbacOutputText, rbacOutputJSON:
+			default:
+				return fmt.Errorf("invalid value %q for --output, must be one of %q or %q",
+					output, rbacOutputText, rbacOutputJSON)
+			}
+
+			drift, err := scaffolds.CheckRBAC(afero.NewOsFs(), *rbacOptions)
+			if err != nil {
+				return err
+			}
+			if err := printRBACDrift(os.Stdout, drift, output); err != nil {
+				return err
+			}
+			if n := len(drift.Missing) + len(drift.Excess); n != 0 {
+				return fmt.Errorf("found %d difference(s), run \"rbac sync\" to update the rules", n)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", rbacOutputText,
+		fmt.Sprintf("format of the differences found, one of %q or %q", rbacOutputText, rbacOutputJSON))
+
+	return cmd
+}
+
+// printRBACDrift writes the differences found by CheckRBAC to w in the output format
+func printRBACDrift(w io.Writer, drift scaffolds.RBACDrift, output string) error {
+	if output == rbacOutputJSON {
+		if drift.Missing == nil {
+			drift.Missing = []scaffolds.RBACPermission{}
+		}
+		if drift.Excess == nil {
+			drift.Excess = []scaffolds.RBACPermission{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(drift)
+	}
+
+	for _, p := range drift.Missing {
+		fmt.Fprintf(w, "missing: %q in API group %q, needed to reconcile %s\n", p.Resource, p.APIGroup, p.Kind)
+	}
+	for _, p := range drift.Excess {
+		fmt.Fprintf(w, "excess: %q in API group %q, granted for %s\n", p.Resource, p.APIGroup, p.Kind)
+	}
+	if len(drift.Missing)+len(drift.Excess) == 0 {
+		fmt.Fprintln(w, "The manager role is up to date.")
+	}
+	return nil
+}
+
+func newRBACSyncCommand(rbacOptions *scaffolds.RBACOptions) *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync",
+		Short: "Generate again the rules of the kinds in watches.yaml in the manager role",
+		Long: `Generate again, in "config/rbac/role.yaml", the rules of the kinds in "watches.yaml" from their
+current charts. The rules generated for kinds that are not in the project anymore are removed,
+and rules are added for the kinds that have none. The other rules are kept as they are.
+`,
+		SilenceUsage: true,
+		RunE: func(*cobra.Command, []string) error {
+			changed, err := scaffolds.SyncRBAC(afero.NewOsFs(), *rbacOptions)
+			if err != nil {
+				return err
+			}
+			if changed {
+				fmt.Println("Updated the manager role.")
+			} else {
+				fmt.Println("The manager role is up to date.")
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+)
+
+func TestPrintRBACDrift(t *testing.T) {
+	drift := scaffolds.RBACDrift{
+		Missing: []scaffolds.RBACPermission{
+			{Kind: "cache.example.com/v1alpha1, Kind=Memcached", APIGroup: "apps", Resource: "deployments"},
+		},
+		Excess: []scaffolds.RBACPermission{
+			{Kind: "cache.example.com/v1alpha1, Kind=Redis", APIGroup: "", Resource: "services"},
+		},
+	}
+	tests := []struct {
+		name   string
+		drift  scaffolds.RBACDrift
+		output string
+		want   string
+	}{
+		{
+			name:   "text",
+			drift:  drift,
+			output: rbacOutputText,
+			want: `missing: "deployments" in API group "apps", needed to reconcile ` +
+				"cache.example.com/v1alpha1, Kind=Memcached\n" +
+				`excess: "services" in API group "", granted for cache.example.com/v1alpha1, Kind=Redis` + "\n",
+		},
+		{
+			name:   "text without differences",
+			output: rbacOutputText,
+			want:   "The manager role is up to date.\n",
+		},
+		{
+			name:   "JSON",
+			drift:  drift,
+			output: rbacOutputJSON,
+			want: `{
+  "missing": [
+    {
+      "kind": "cache.example.com/v1alpha1, Kind=Memcached",
+      "apiGroup": "apps",
+      "resource": "deployments"
+    }
+  ],
+  "excess": [
+    {
+      "kind": "cache.example.com/v1alpha1, Kind=Redis",
+      "apiGroup": "",
+      "resource": "services"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:   "JSON without differences",
+			output: rbacOutputJSON,
+			want:   "{\n  \"missing\": [],\n  \"excess\": []\n}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			checkErr(t, printRBACDrift(buf, tt.drift, tt.output), "")
+			if buf.String() != tt.want {
+				t.Errorf("expected output:\n%s\ngot:\n%s", tt.want, buf)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/chartutil"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/api"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/controllers"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/crd"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/hack"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/rbac"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/watches"
+	"helm.sh/helm/v3/pkg/chart"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugins"
+)
+
+// Modes used to discover the resources rendered by a chart when generating its RBAC rules.
+const (
+	RBACDiscoveryAuto    = rbac.DiscoveryAuto
+	RBACDiscoveryCluster = rbac.DiscoveryCluster
+	RBACDiscoveryStatic  = rbac.DiscoveryStatic
+)
+
+// RBACOptions configures how the manager role rules of a chart are generated
+type RBACOptions struct {
+	// Discovery is the mode used to discover the resources rendered by the chart
+	Discovery string
+
+	// ResourcesFile is an optional YAML file with additional kind to resource mappings
+	ResourcesFile string
+}
+
+// CRDOptions configures how the CustomResourceDefinition of a chart-backed kind is generated
+type CRDOptions struct {
+	// InferSchema indicates whether the schema of the spec is inferred from the
+	// default values of the chart when the chart has no values schema
+	InferSchema bool
+}
+
+// WatchOptions configures the watch of a chart-backed kind in watches.yaml, the options
+// left to their zero value are omitted so that the defaults of the Helm reconciler apply
+type WatchOptions struct {
+	// WatchDependentResources indicates whether the resources created by the chart are watched
+	WatchDependentResources *bool
+
+	// OverrideValues are chart values overriding those of the custom resources
+	OverrideValues map[string]string
+
+	// ReconcilePeriod is the period after which the custom resources are reconciled again
+	ReconcilePeriod time.Duration
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles of the kind
+	MaxConcurrentReconciles int
+}
+
+var _ plugins.Scaffolder = &apiScaffolder{}
+
+// apiScaffolder contains configuration for generating scaffolding for a
+// Kubernetes API that is reconciled either by a Helm chart or by a Go controller.
+type apiScaffolder struct {
+	// fs is the filesystem that will be used by the scaffolder
+	fs machinery.Filesystem
+
+	config   config.Config
+	resource resource.Resource
+	// chart is the chart reconciling resource, nil if it is reconciled by a Go controller
+	chart *chart.Chart
+
+	crdOptions   CRDOptions
+	rbacOptions  RBACOptions
+	watchOptions WatchOptions
+
+	// force indicates whether to overwrite existing files
+	force bool
+}
+
+// NewAPIScaffolder returns a new plugins.Scaffolder for API creation operations. Kinds with a
+// controller are reconciled by a Go controller, other kinds by a Helm reconciler installing chrt.
+func NewAPIScaffolder(config config.Config, res resource.Resource, chrt *chart.Chart,
+	crdOptions CRDOptions, rbacOptions RBACOptions, watchOptions WatchOptions, force bool) plugins.Scaffolder {
+	return &apiScaffolder{
+		config:       config,
+		resource:     res,
+		chart:        chrt,
+		crdOptions:   crdOptions,
+		rbacOptions:  rbacOptions,
+		watchOptions: watchOptions,
+		force:        force,
+	}
+}
+
+// InjectFS implements plugins.Scaffolder
+func (s *apiScaffolder) InjectFS(fs machinery.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements plugins.Scaffolder
+func (s *apiScaffolder) Scaffold() error {
+	fmt.Println("Writing scaffold for you to edit...")
+
+	var chartPath string
+	var watchesContent []byte
+	var clusterRules, namespacedRules []rbacv1.PolicyRule
+	if s.chart != nil {
+		chartPath = chartutil.ChartPath(s.chart)
+
+		// Fail before writing any file if the watch or the rules can't be added
+		var err error
+		if watchesContent, err = s.updatedWatches(chartPath); err != nil {
+			return err
+		}
+		if clusterRules, namespacedRules, err = rbac.ChartRules(s.chart, s.rbacOptions.Discovery, s.rbacOptions.ResourcesFile); err != nil {
+			return fmt.Errorf("error generating the RBAC rules of chart %q: %v", s.chart.Name(), err)
+		}
+
+		exists, err := afero.Exists(s.fs.FS, chartPath)
+		if err != nil {
+			return fmt.Errorf("error checking for chart directory %q: %v", chartPath, err)
+		}
+		if exists && !s.force {
+			return fmt.Errorf("chart directory %q already exists, use --force to overwrite it", chartPath)
+		}
+	}
+
+	// Keep track of these values before the update
+	doTypes := s.resource.Path != ""
+	doController := s.resource.HasController()
+
+	if err := s.config.UpdateResource(s.resource); err != nil {
+		return fmt.Errorf("error updating resource: %v", err)
+	}
+
+	// Load the boilerplate, needed by the Go files
+	var boilerplate string
+	if doTypes {
+		bp, err := afero.ReadFile(s.fs.FS, hack.DefaultBoilerplatePath)
+		if err != nil {
+			return fmt.Errorf("error reading boilerplate: %v", err)
+		}
+		boilerplate = string(bp)
+	}
+
+	// Initialize the machinery.Scaffold that will write the files to disk
+	scaffold := machinery.NewScaffold(s.fs,
+		machinery.WithConfig(s.config),
+		machinery.WithBoilerplate(boilerplate),
+		machinery.WithResource(&s.resource),
+	)
+
+	// Kinds with a package path get Go types. The spec of chart-backed kinds
+	// mirrors the values of the chart, so that Go controllers can read them
+	if doTypes {
+		if err := scaffold.Execute(
+			&api.Types{Chart: s.chart, Force: s.force},
+			&api.Group{},
+		); err != nil {
+			return fmt.Errorf("error scaffolding Go types: %v", err)
+		}
+	}
+
+	if doController {
+		if err := scaffold.Execute(
+			&controllers.Controller{ControllerRuntimeVersion: ControllerRuntimeVersion, Force: s.force},
+		); err != nil {
+			return fmt.Errorf("error scaffolding controller: %v", err)
+		}
+	}
+
+	// Chart-backed kinds are reconciled by the Helm reconcilers that main.go
+	// creates from watches.yaml. Their Go types are added to the scheme of the
+	// manager, so that Go controllers can read them as typed objects.
+	if err := scaffold.Execute(
+		&templates.MainUpdater{WireResource: doTypes, WireController: doController},
+	); err != nil {
+		return fmt.Errorf("error updating main.go: %v", err)
+	}
+
+	builders := []machinery.Builder{
+		&crd.CRD{Chart: s.chart, InferSchema: s.crdOptions.InferSchema},
+		&crd.Kustomization{},
+	}
+	if err := scaffold.Execute(builders...); err != nil {
+		return fmt.Errorf("error scaffolding APIs: %v", err)
+	}
+
+	// The rules of the Go controllers are generated by controller-gen from their RBAC markers
+	if s.chart != nil {
+		if err := s.updateRole(clusterRules, namespacedRules); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(s.fs.FS, watchesFile, watchesContent, 0644); err != nil {
+			return fmt.Errorf("error writing %s: %v", watchesFile, err)
+		}
+	}
+
+	if doController {
+		if err := s.updateGoAPIPaths(); err != nil {
+			return err
+		}
+	}
+
+	// The chart is written last, since it replaces the directory of an existing chart
+	if s.chart != nil {
+		if err := chartutil.WriteChart(s.fs.FS, s.chart); err != nil {
+			return fmt.Errorf("error writing chart: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// makefile is the Makefile of the project, which lists the packages of the Go kinds in GO_API_PATHS
+const makefile = "Makefile"
+
+var goAPIPathsRegexp = regexp.MustCompile(`(?m)^GO_API_PATHS \?=[ \t]*(.*)$`)
+
+// updateGoAPIPaths adds the package of the Go types of the resource to the packages whose CRDs are
+// generated by controller-gen, so that the CRDs generated from charts are not overwritten.
+func (s *apiScaffolder) updateGoAPIPaths() error {
+	b, err := afero.ReadFile(s.fs.FS, makefile)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", makefile, err)
+	}
+	match := goAPIPathsRegexp.FindSubmatchIndex(b)
+	if match == nil {
+		return fmt.Errorf("error updating %s: no GO_API_PATHS variable listing the packages of the Go kinds",
+			makefile)
+	}
+
+	pkg := "./" + path.Clean(strings.TrimPrefix(s.resource.Path, s.config.GetRepository()+"/"))
+	var paths []string
+	if value := strings.TrimSpace(string(b[match[2]:match[3]])); value != "" {
+		paths = strings.Split(value, ";")
+	}
+	for _, p := range paths {
+		if p == pkg {
+			return nil
+		}
+	}
+	paths = append(paths, pkg)
+
+	content := append([]byte{}, b[:match[0]]...)
+	content = append(content, "GO_API_PATHS ?= "+strings.Join(paths, ";")...)
+	content = append(content, b[match[1]:]...)
+	if err := afero.WriteFile(s.fs.FS, makefile, content, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", makefile, err)
+	}
+	return nil
+}
+
+// updateRole adds the rules needed to reconcile the chart-backed resource, including the rules of the chart,
+// to role.yaml, and to cluster_role.yaml for the cluster-scoped ones if the project has it. If the roles
+// already have rules for the resource, e.g. when its API is created again with force, they are replaced.
+func (s *apiScaffolder) updateRole(clusterRules, namespacedRules []rbacv1.PolicyRule) error {
+	namespaced, err := afero.Exists(s.fs.FS, clusterRoleFile)
+	if err != nil {
+		return fmt.Errorf("error checking for %s: %v", clusterRoleFile, err)
+	}
+	roleKind, clusterRoleKind := scopeChartKind(&s.resource, clusterRules, namespacedRules, namespaced)
+
+	if err := upsertRoleFile(s.fs.FS, roleFile, roleKind); err != nil {
+		return err
+	}
+	if namespaced {
+		return upsertRoleFile(s.fs.FS, clusterRoleFile, clusterRoleKind)
+	}
+	return nil
+}
+
+// upsertRoleFile replaces or adds the section of the kind k in the role at path, see rbac.UpsertRole
+func upsertRoleFile(fs afero.Fs, path string, k rbac.ChartKind) error {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return fmt.Errorf("error reading %s: %v", path, err)
+	}
+	content, err := rbac.UpsertRole(b, k)
+	if err != nil {
+		return fmt.Errorf("error updating %s: %v", path, err)
+	}
+	if err := afero.WriteFile(fs, path, content, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return nil
+}
+
+// watchesFile lists the kinds reconciled by Helm reconcilers, and their charts
+const watchesFile = "watches.yaml"
+
+// updatedWatches returns the content of watches.yaml with the watch of the resource added, or
+// updated if it exists and force is set. The other watches and the comments of the file are kept.
+func (s *apiScaffolder) updatedWatches(chartPath string) ([]byte, error) {
+	b, err := afero.ReadFile(s.fs.FS, watchesFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", watchesFile, err)
+	}
+
+	w := watches.Watch{
+		Group:                   s.resource.QualifiedGroup(),
+		Version:                 s.resource.Version,
+		Kind:                    s.resource.Kind,
+		ChartPath:               chartPath,
+		WatchDependentResources: s.watchOptions.WatchDependentResources,
+		OverrideValues:          s.watchOptions.OverrideValues,
+	}
+	if s.watchOptions.ReconcilePeriod != 0 {
+		w.ReconcilePeriod = &metav1.Duration{Duration: s.watchOptions.ReconcilePeriod}
+	}
+	if s.watchOptions.MaxConcurrentReconciles != 0 {
+		w.MaxConcurrentReconciles = &s.watchOptions.MaxConcurrentReconciles
+	}
+
+	content, err := watches.Upsert(b, w, s.force)
+	if errors.Is(err, watches.ErrAlreadyWatched) {
+		return nil, fmt.Errorf("error updating %s: %v, use --force to update its watch", watchesFile, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error updating %s: %v", watchesFile, err)
+	}
+	return content, nil
+}
@@ -0,0 +1,348 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/util"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/api"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/hack"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/manager"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugins"
+)
+
+var _ plugins.Scaffolder = &editScaffolder{}
+
+// editScaffolder contains configuration for updating the layout of an existing project.
+type editScaffolder struct {
+	// fs is the filesystem that will be used by the scaffolder
+	fs machinery.Filesystem
+
+	config config.Config
+
+	// multigroup and componentConfig are the layout of the project after the edit
+	multigroup      bool
+	componentConfig bool
+
+	// license and owner of the boilerplate, the current ones are kept if empty
+	license string
+	owner   string
+}
+
+// NewEditScaffolder returns a new plugins.Scaffolder for project edit operations
+func NewEditScaffolder(config config.Config, multigroup, componentConfig bool, license, owner string) plugins.Scaffolder {
+	return &editScaffolder{
+		config:          config,
+		multigroup:      multigroup,
+		componentConfig: componentConfig,
+		license:         license,
+		owner:           owner,
+	}
+}
+
+// InjectFS implements plugins.Scaffolder
+func (s *editScaffolder) InjectFS(fs machinery.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements plugins.Scaffolder
+func (s *editScaffolder) Scaffold() error {
+	// The component config switch is tried out first on a copy-on-write layer of the project, so that
+	// the project is left unchanged if main.go or the manager manifests differ from what init scaffolded
+	if s.componentConfig != s.config.IsComponentConfig() {
+		layer := afero.NewCopyOnWriteFs(afero.NewReadOnlyFs(s.fs.FS), afero.NewMemMapFs())
+		if err := s.editComponentConfigFiles(layer); err != nil {
+			return err
+		}
+	}
+
+	if s.license != "" || s.owner != "" {
+		if err := s.updateBoilerplate(); err != nil {
+			return err
+		}
+	}
+
+	if s.componentConfig != s.config.IsComponentConfig() {
+		if err := s.updateComponentConfig(); err != nil {
+			return err
+		}
+	}
+
+	// Only new APIs are created with the new layout, existing ones are left where they are
+	if s.multigroup != s.config.IsMultiGroup() {
+		if s.multigroup {
+			if err := s.config.SetMultiGroup(); err != nil {
+				return err
+			}
+		} else {
+			if err := s.config.ClearMultiGroup(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyrightRegexp matches the copyright line of the boilerplate, capturing its year and owner.
+var copyrightRegexp = regexp.MustCompile(`Copyright (\d+)(?: (.*))?\.`)
+
+// updateBoilerplate overwrites the boilerplate with the new license and owner. The Go files
+// scaffolded from now on use it, existing files keep their header.
+func (s *editScaffolder) updateBoilerplate() error {
+	current, err := afero.ReadFile(s.fs.FS, hack.DefaultBoilerplatePath)
+	if err != nil {
+		return fmt.Errorf("error reading boilerplate: %v", err)
+	}
+
+	bpFile := &hack.Boilerplate{
+		License: s.license,
+		Owner:   s.owner,
+	}
+	bpFile.Path = hack.DefaultBoilerplatePath
+	bpFile.IfExistsAction = machinery.OverwriteFile
+
+	// Keep the year, and the license or owner that are not changed
+	if m := copyrightRegexp.FindStringSubmatch(string(current)); m != nil {
+		bpFile.Year = m[1]
+		if bpFile.Owner == "" {
+			bpFile.Owner = m[2]
+		}
+	}
+	if bpFile.License == "" && !strings.Contains(string(current), "Licensed under the Apache License") {
+		bpFile.License = "none"
+	}
+
+	scaffold := machinery.NewScaffold(s.fs, machinery.WithConfig(s.config))
+	if err := scaffold.Execute(bpFile); err != nil {
+		return fmt.Errorf("error updating boilerplate: %v", err)
+	}
+	return nil
+}
+
+// updateComponentConfig switches the manager between command-line flags and a component config file.
+// Only the parts of main.go that differ, the flags and the loading of the config file, are replaced, so
+// the changes to the rest of main.go are kept. main.go must have them as scaffolded by init, and the
+// manager manifests as customized by init.
+func (s *editScaffolder) updateComponentConfig() error {
+	if err := s.editComponentConfigFiles(s.fs.FS); err != nil {
+		return err
+	}
+
+	if s.componentConfig {
+		if err := s.config.SetComponentConfig(); err != nil {
+			return err
+		}
+	} else {
+		if err := s.config.ClearComponentConfig(); err != nil {
+			return err
+		}
+	}
+
+	leaderElectionID := LeaderElectionID(s.config)
+
+	boilerplate, err := afero.ReadFile(s.fs.FS, hack.DefaultBoilerplatePath)
+	if err != nil {
+		return fmt.Errorf("error reading boilerplate: %v", err)
+	}
+
+	scaffold := machinery.NewScaffold(s.fs,
+		machinery.WithConfig(s.config),
+		machinery.WithBoilerplate(string(boilerplate)),
+	)
+
+	builders := []machinery.Builder{&manager.ControllerManagerConfig{LeaderElectionID: leaderElectionID}}
+	// The ProjectConfig type is left in place when the component config is disabled
+	if s.componentConfig {
+		builders = append(builders, &api.ProjectConfigGroup{}, &api.ProjectConfig{})
+	}
+	if err := scaffold.Execute(builders...); err != nil {
+		return fmt.Errorf("error scaffolding the component config: %v", err)
+	}
+
+	return nil
+}
+
+// editComponentConfigFiles switches main.go and the manager manifests of fs to the component config
+// mode of s
+func (s *editScaffolder) editComponentConfigFiles(fs afero.Fs) error {
+	leaderElectionID := LeaderElectionID(s.config)
+	mainFile := (&templates.MainUpdater{}).GetPath()
+	for _, e := range mainComponentConfigEdits(s.config.GetRepository(), leaderElectionID) {
+		old, new := e.flags, e.componentConfig
+		if !s.componentConfig {
+			old, new = new, old
+		}
+		if err := util.ReplaceInFile(fs, mainFile, old, new); err != nil {
+			return fmt.Errorf("error updating %s, it must configure the manager as scaffolded by init: %v",
+				mainFile, err)
+		}
+	}
+
+	if err := updateComponentConfigManifests(fs, leaderElectionID, s.componentConfig); err != nil {
+		return fmt.Errorf("error updating manager manifests: %v", err)
+	}
+	return nil
+}
+
+// updateComponentConfigManifests switches the manager manifests between passing the options of the
+// manager as arguments, as customized by init, and mounting controller_manager_config.yaml.
+func updateComponentConfigManifests(fs afero.Fs, leaderElectionID string, componentConfig bool) error {
+	managerFile := filepath.Join("config", "manager", "manager.yaml")
+	authProxyPatchFile := filepath.Join("config", "default", "manager_auth_proxy_patch.yaml")
+	kustomizationFile := filepath.Join("config", "default", "kustomization.yaml")
+
+	if componentConfig {
+		err := util.ReplaceRegexInFile(fs, managerFile,
+			`\n        args:\n        - --leader-elect\n(?:        - --leader-election-id=.*\n)?`, "\n")
+		if err != nil {
+			return err
+		}
+		err = util.ReplaceRegexInFile(fs, authProxyPatchFile,
+			`\n      - name: manager\n        args:\n(?:        - .*\n)*`, "\n")
+		if err != nil {
+			return err
+		}
+		return util.ReplaceRegexInFile(fs, kustomizationFile,
+			`(?m)^#- manager_config_patch.yaml`, "- manager_config_patch.yaml")
+	}
+
+	err := util.InsertCode(fs, managerFile,
+		"\n        - /manager",
+		fmt.Sprintf("\n        args:\n        - --leader-elect\n        - --leader-election-id=%s", leaderElectionID))
+	if err != nil {
+		return err
+	}
+	err = util.InsertCode(fs, authProxyPatchFile,
+		"\n          name: https",
+		fmt.Sprintf(`
+      - name: manager
+        args:
+        - "--health-probe-bind-address=:8081"
+        - "--metrics-bind-address=127.0.0.1:8080"
+        - "--leader-elect"
+        - "--leader-election-id=%s"`, leaderElectionID))
+	if err != nil {
+		return err
+	}
+	return util.ReplaceRegexInFile(fs, kustomizationFile,
+		`(?m)^- manager_config_patch.yaml`, "#- manager_config_patch.yaml")
+}
+
+// mainEdit is a part of main.go, as scaffolded by init for a manager configured by command-line flags,
+// and for a manager configured by a component config file
+type mainEdit struct {
+	flags           string
+	componentConfig string
+}
+
+// mainComponentConfigEdits returns the parts of main.go that differ between a manager configured by
+// command-line flags and one configured by a component config file, see templates.Main
+func mainComponentConfigEdits(repo, leaderElectionID string) []mainEdit {
+	return []mainEdit{
+		{
+			flags: `	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+`,
+			componentConfig: `	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	configv1alpha1 "` + repo + `/api/config/v1alpha1"
+`,
+		},
+		{
+			flags: `	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+`,
+			componentConfig: `	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
+`,
+		},
+		{
+			flags: `	var metricsAddr string
+	var enableLeaderElection bool
+	var probeAddr string
+	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
+	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
+		"Enable leader election for controller manager. "+
+			"Enabling this will ensure there is only one active controller manager.")
+`,
+			componentConfig: `	var configFile string
+	flag.StringVar(&configFile, "config", "",
+		"The controller will load its initial configuration from this file. "+
+			"Omit this flag to use the default configuration values. "+
+			"Command-line flags override configuration from this file.")
+`,
+		},
+		{
+			// The leader election ID is set in the config file
+			flags:           `flag.StringVar(&leaderElectionID, "leader-election-id", "` + leaderElectionID + `",`,
+			componentConfig: `flag.StringVar(&leaderElectionID, "leader-election-id", "",`,
+		},
+		{
+			flags: `	options := ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		Port:                    9443,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+	}
+`,
+			componentConfig: `	var err error
+	ctrlConfig := configv1alpha1.ProjectConfig{}
+	// Options that are already set take precedence over the config file
+	options := ctrl.Options{
+		Scheme:                  scheme,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+	}
+	if configFile != "" {
+		options, err = options.AndFrom(ctrl.ConfigFile().AtPath(configFile).OfKind(&ctrlConfig))
+		if err != nil {
+			setupLog.Error(err, "unable to load the config file")
+			os.Exit(1)
+		}
+	}
+
+	// The --watches-file flag overrides the watches file of the config file
+	watchesFileSet := false
+	flag.Visit(func(f *flag.Flag) {
+		watchesFileSet = watchesFileSet || f.Name == "watches-file"
+	})
+	if ctrlConfig.WatchesFile != "" && !watchesFileSet {
+		watchesFile = ctrlConfig.WatchesFile
+	}
+	var reconcilerOptions []reconciler.Option
+	if ctrlConfig.MaxConcurrentReconciles != nil {
+		reconcilerOptions = append(reconcilerOptions,
+			reconciler.WithMaxConcurrentReconciles(*ctrlConfig.MaxConcurrentReconciles))
+	}
+`,
+		},
+		{
+			flags:           `setupHelmReconcilers(mgr, watchesFile, "")`,
+			componentConfig: `setupHelmReconcilers(mgr, watchesFile, ctrlConfig.HelmChartsDir, reconcilerOptions...)`,
+		},
+	}
+}
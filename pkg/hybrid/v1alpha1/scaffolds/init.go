@@ -22,25 +22,55 @@ import (
 
 	"github.com/spf13/afero"
 	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/api"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/crd"
 	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/hack"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/kdefault"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/manager"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/prometheus"
 	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/rbac"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/watches"
 	"sigs.k8s.io/kubebuilder/v3/pkg/config"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
 	"sigs.k8s.io/kubebuilder/v3/pkg/plugins"
 )
 
 const (
+	// GoVersion is the minimum Go version of the project, set in its go.mod
+	GoVersion = "1.16"
 	// ControllerRuntimeVersion is the kubernetes-sigs/controller-runtime version to be used in the project
 	ControllerRuntimeVersion = "v0.8.3"
 	// ControllerToolsVersion is the kubernetes-sigs/controller-tools version to be used in the project
 	ControllerToolsVersion = "v0.5.0"
 	// KustomizeVersion is the kubernetes-sigs/kustomize version to be used in the project
 	KustomizeVersion = "v3.8.7"
+	// HelmOperatorVersion is the joelanford/helm-operator version, which provides the Helm reconciler,
+	// to be used in the project
+	HelmOperatorVersion = "v0.0.7"
+	// HelmVersion is the helm.sh/helm/v3 version required by HelmOperatorVersion
+	HelmVersion = "v3.5.0"
+	// KubernetesVersion is the k8s.io/client-go and k8s.io/apimachinery version required by ControllerRuntimeVersion
+	KubernetesVersion = "v0.20.2"
+	// LogrVersion is the go-logr/logr version required by ControllerRuntimeVersion
+	LogrVersion = "v0.3.0"
+	// YAMLVersion is the sigs.k8s.io/yaml version required by ControllerRuntimeVersion
+	YAMLVersion = "v1.2.0"
+	// DockerDistributionVersion is the docker/distribution version which HelmVersion replaces its dependency
+	// with. Unlike HelmVersion, the project does not replace Azure/go-autorest, whose replacement lacks the
+	// package imported by the Azure/go-autorest/autorest version required by KubernetesVersion.
+	DockerDistributionVersion = "v0.0.0-20191216044856-a8371794149d"
 
 	imageName = "controller:latest"
 )
 
+// LeaderElectionID returns the name of the lock used for the leader election of the manager. It is the
+// default of the --leader-election-id flag of main.go, and is set in the manifests of the manager.
+func LeaderElectionID(c config.Config) string {
+	return c.GetProjectName()
+}
+
 var hybridOperatorVersion = "0.1.0"
+
 var _ plugins.Scaffolder = &initScaffolder{}
 
 type initScaffolder struct {
@@ -51,15 +81,19 @@ type initScaffolder struct {
 	boilerplatePath string
 	license         string
 	owner           string
+
+	// watchNamespaces are the namespaces watched by the manager, all namespaces if empty
+	watchNamespaces []string
 }
 
 // NewInitScaffolder returns a new plugins.Scaffolder for project initialization operations
-func NewInitScaffolder(config config.Config, license, owner string) plugins.Scaffolder {
+func NewInitScaffolder(config config.Config, license, owner string, watchNamespaces []string) plugins.Scaffolder {
 	return &initScaffolder{
 		config:          config,
 		boilerplatePath: hack.DefaultBoilerplatePath,
 		license:         license,
 		owner:           owner,
+		watchNamespaces: watchNamespaces,
 	}
 }
 
@@ -102,13 +136,76 @@ func (s *initScaffolder) Scaffold() error {
 		machinery.WithBoilerplate(string(boilerplate)),
 	)
 
+	if s.config.IsComponentConfig() {
+		if err := scaffold.Execute(
+			&api.ProjectConfigGroup{},
+			&api.ProjectConfig{},
+		); err != nil {
+			return fmt.Errorf("error scaffolding ProjectConfig type: %v", err)
+		}
+	}
+
+	leaderElectionID := LeaderElectionID(s.config)
+	namespaced := len(s.watchNamespaces) != 0
+
+	// The roles and role bindings of the watched namespaces are moved to them by patches of the default overlay
+	for _, ns := range s.watchNamespaces {
+		if err := scaffold.Execute(
+			&kdefault.RoleNamespacePatch{Namespace: ns},
+			&kdefault.RoleBindingNamespacePatch{Namespace: ns},
+		); err != nil {
+			return fmt.Errorf("error scaffolding the role patches of namespace %q: %v", ns, err)
+		}
+	}
+	if namespaced {
+		if err := scaffold.Execute(
+			&rbac.ClusterRole{},
+			&rbac.ClusterRoleBinding{},
+		); err != nil {
+			return fmt.Errorf("error scaffolding the cluster role: %v", err)
+		}
+	}
+
 	return scaffold.Execute(
-		&templates.Main{},
-		&templates.GoMod{ControllerRuntimeVersion: ControllerRuntimeVersion},
+		&templates.Main{LeaderElectionID: leaderElectionID},
+		&watches.Loader{},
+		&templates.GoMod{
+			GoVersion:                 GoVersion,
+			ControllerRuntimeVersion:  ControllerRuntimeVersion,
+			HelmOperatorVersion:       HelmOperatorVersion,
+			HelmVersion:               HelmVersion,
+			KubernetesVersion:         KubernetesVersion,
+			LogrVersion:               LogrVersion,
+			YAMLVersion:               YAMLVersion,
+			DockerDistributionVersion: DockerDistributionVersion,
+		},
 		&templates.GitIgnore{},
-		&rbac.ManagerRole{},
+		&templates.Dockerfile{GoVersion: GoVersion},
+		&templates.DockerIgnore{},
+		&rbac.Kustomization{Namespaced: namespaced},
+		&rbac.ManagerRole{WatchNamespaces: s.watchNamespaces},
+		&rbac.RoleBinding{WatchNamespaces: s.watchNamespaces},
+		&rbac.GoRole{},
+		&rbac.GoRoleBinding{WatchNamespaces: s.watchNamespaces},
+		&rbac.ServiceAccount{},
+		&rbac.LeaderElectionRole{},
+		&rbac.LeaderElectionRoleBinding{},
+		&rbac.AuthProxyRole{},
+		&rbac.AuthProxyRoleBinding{},
+		&rbac.AuthProxyService{},
+		&rbac.AuthProxyClientRole{},
+		&manager.Kustomization{},
+		&manager.Config{Image: imageName},
+		&manager.ControllerManagerConfig{LeaderElectionID: leaderElectionID},
+		&kdefault.Kustomization{WatchNamespaces: s.watchNamespaces},
+		&kdefault.ManagerAuthProxyPatch{},
+		&kdefault.ManagerConfigPatch{},
+		&prometheus.Kustomization{},
+		&prometheus.Monitor{},
+		&crd.Kustomization{},
 		&templates.Makefile{
 			Image:                    imageName,
+			BoilerplatePath:          s.boilerplatePath,
 			KustomizeVersion:         KustomizeVersion,
 			HybridOperatorVersion:    hybridOperatorVersion,
 			ControllerToolsVersion:   ControllerToolsVersion,
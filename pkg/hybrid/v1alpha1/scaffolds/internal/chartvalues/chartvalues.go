@@ -0,0 +1,81 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chartvalues infers the types of the default values of a chart, as parsed by Helm, shared by
+// the schema of the CRD and the Go types of the chart-backed kinds.
+package chartvalues
+
+import (
+	"sort"
+)
+
+// Structural types of the scalar values.
+const (
+	String  = "string"
+	Boolean = "boolean"
+	Integer = "integer"
+	Number  = "number"
+)
+
+// ScalarType returns the structural type of the scalar v, one of String, Boolean, Integer or Number,
+// or "" if v is not a scalar. Integral numbers are integers.
+func ScalarType(v interface{}) string {
+	switch n := v.(type) {
+	case string:
+		return String
+	case bool:
+		return Boolean
+	case int, int32, int64:
+		return Integer
+	case float64:
+		if _, ok := IntValue(n); ok {
+			return Integer
+		}
+		return Number
+	}
+	return ""
+}
+
+// ItemsType returns the structural type shared by every element of values, or "" if values is empty
+// or its elements are not all scalars of the same type.
+func ItemsType(values []interface{}) string {
+	typ := ""
+	for _, v := range values {
+		t := ScalarType(v)
+		if t == "" || (typ != "" && t != typ) {
+			return ""
+		}
+		typ = t
+	}
+	return typ
+}
+
+// IntValue returns the JSON number v as an integer, if it is integral.
+func IntValue(v interface{}) (int64, bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// SortedKeys returns the keys of m, in order.
+func SortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chartvalues
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestItemsType(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   string
+	}{
+		{name: "empty", values: []interface{}{}, want: ""},
+		{name: "strings", values: []interface{}{"a", "b"}, want: String},
+		{name: "booleans", values: []interface{}{true, false}, want: Boolean},
+		{name: "integers", values: []interface{}{1, int64(2), float64(3)}, want: Integer},
+		{name: "numbers", values: []interface{}{1.5, 2.5}, want: Number},
+		{name: "integers and numbers", values: []interface{}{float64(1), 1.5}, want: ""},
+		{name: "mixed", values: []interface{}{"a", 1}, want: ""},
+		{name: "null", values: []interface{}{nil}, want: ""},
+		{name: "objects", values: []interface{}{map[string]interface{}{}}, want: ""},
+		{name: "lists", values: []interface{}{[]interface{}{"a"}}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ItemsType(tt.values); got != tt.want {
+				t.Errorf("expected type %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIntValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   int64
+		wantOK bool
+	}{
+		{name: "integral number", value: float64(-3), want: -3, wantOK: true},
+		{name: "fractional number", value: 0.5},
+		{name: "string", value: "3"},
+		{name: "int", value: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := IntValue(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("expected %d, %v, got %d, %v", tt.want, tt.wantOK, got, ok)
+			}
+		})
+	}
+}
+
+func TestSortedKeys(t *testing.T) {
+	keys := SortedKeys(map[string]interface{}{"b": 1, "a": nil, "C": "c"})
+	if want := []string{"C", "a", "b"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+}
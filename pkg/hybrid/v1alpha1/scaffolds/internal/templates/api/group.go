@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Group{}
+
+// Group scaffolds the file that defines the registration methods for a certain group and version
+type Group struct {
+	machinery.TemplateMixin
+	machinery.MultiGroupMixin
+	machinery.BoilerplateMixin
+	machinery.ResourceMixin
+}
+
+// SetTemplateDefaults implements file.Template
+func (f *Group) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join(apiDir(f.MultiGroup, f.Resource.Group), "groupversion_info.go")
+	}
+	f.Path = f.Resource.Replacer().Replace(f.Path)
+
+	f.TemplateBody = groupTemplate
+
+	// The file is shared by every kind of the group version
+	f.IfExistsAction = machinery.SkipFile
+
+	return nil
+}
+
+// apiDir returns the directory of the Go types of a group version.
+func apiDir(multiGroup bool, group string) string {
+	if !multiGroup {
+		return filepath.Join("api", "%[version]")
+	}
+	if group == "" {
+		return filepath.Join("apis", "%[version]")
+	}
+	return filepath.Join("apis", "%[group]", "%[version]")
+}
+
+//nolint:lll
+const groupTemplate = `{{ .Boilerplate }}
+
+// Package {{ .Resource.Version }} contains API Schema definitions for the {{ .Resource.Group }} {{ .Resource.Version }} API group
+//+kubebuilder:object:generate=true
+//+groupName={{ .Resource.QualifiedGroup }}
+package {{ .Resource.Version }}
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "{{ .Resource.QualifiedGroup }}", Version: "{{ .Resource.Version }}"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+`
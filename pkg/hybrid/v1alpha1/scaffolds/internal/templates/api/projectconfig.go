@@ -0,0 +1,129 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// projectConfigDir is the directory of the ProjectConfig type, imported by main.go in component config mode
+var projectConfigDir = filepath.Join("api", "config", "v1alpha1")
+
+var _ machinery.Template = &ProjectConfigGroup{}
+
+// ProjectConfigGroup scaffolds the file that defines the registration methods of the ProjectConfig type
+type ProjectConfigGroup struct {
+	machinery.TemplateMixin
+	machinery.BoilerplateMixin
+	machinery.DomainMixin
+}
+
+// SetTemplateDefaults implements file.Template
+func (f *ProjectConfigGroup) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join(projectConfigDir, "groupversion_info.go")
+	}
+
+	f.TemplateBody = projectConfigGroupTemplate
+
+	return nil
+}
+
+// The package is skipped by the CRD generator, the ProjectConfig is read from a file and never served.
+const projectConfigGroupTemplate = `{{ .Boilerplate }}
+
+// Package v1alpha1 contains the configuration API of the manager
+//+kubebuilder:object:generate=true
+//+kubebuilder:skip
+//+groupName=config.{{ .Domain }}
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+)
+
+var (
+	// GroupVersion is group version used to register these objects
+	GroupVersion = schema.GroupVersion{Group: "config.{{ .Domain }}", Version: "v1alpha1"}
+
+	// SchemeBuilder is used to add go types to the GroupVersionKind scheme
+	SchemeBuilder = &scheme.Builder{GroupVersion: GroupVersion}
+
+	// AddToScheme adds the types in this group-version to the given scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+`
+
+var _ machinery.Template = &ProjectConfig{}
+
+// ProjectConfig scaffolds the file that defines the ProjectConfig type, loaded by
+// the manager from the file passed with --config
+type ProjectConfig struct {
+	machinery.TemplateMixin
+	machinery.BoilerplateMixin
+}
+
+// SetTemplateDefaults implements file.Template
+func (f *ProjectConfig) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join(projectConfigDir, "projectconfig_types.go")
+	}
+
+	f.TemplateBody = projectConfigTemplate
+
+	return nil
+}
+
+const projectConfigTemplate = `{{ .Boilerplate }}
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cfg "sigs.k8s.io/controller-runtime/pkg/config/v1alpha1"
+)
+
+//+kubebuilder:object:root=true
+
+// ProjectConfig is the Schema for the projectconfigs API, it configures the
+// manager and the Helm reconcilers of the project
+type ProjectConfig struct {
+	metav1.TypeMeta ` + "`" + `json:",inline"` + "`" + `
+
+	// ControllerManagerConfigurationSpec returns the configurations for controllers
+	cfg.ControllerManagerConfigurationSpec ` + "`" + `json:",inline"` + "`" + `
+
+	// WatchesFile is the watches file listing the kinds reconciled by Helm
+	// reconcilers, and their charts. The --watches-file flag overrides it.
+	WatchesFile string ` + "`" + `json:"watchesFile,omitempty"` + "`" + `
+
+	// HelmChartsDir is the directory the relative chart paths of the watches
+	// file are resolved against, i.e. the directory containing the helm-charts
+	// directory of the project, since the chart paths start with helm-charts/.
+	// Defaults to the working directory of the manager.
+	HelmChartsDir string ` + "`" + `json:"helmChartsDir,omitempty"` + "`" + `
+
+	// MaxConcurrentReconciles is the maximum number of concurrent reconciles of
+	// the Helm reconcilers whose watch does not set it.
+	MaxConcurrentReconciles *int ` + "`" + `json:"maxConcurrentReconciles,omitempty"` + "`" + `
+}
+
+func init() {
+	SchemeBuilder.Register(&ProjectConfig{})
+}
+`
@@ -0,0 +1,223 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/chartvalues"
+)
+
+// jsonType is the Go type of values whose type can not be inferred.
+const jsonType = "apiextensionsv1.JSON"
+
+// goStruct is a Go struct generated from an object of the values of a chart.
+type goStruct struct {
+	name   string
+	doc    string
+	fields []goField
+}
+
+// goField is a field of a goStruct, mirroring a single chart value.
+type goField struct {
+	name    string
+	key     string
+	goType  string
+	markers []string
+}
+
+// specGenerator generates the Go structs mirroring the values of a chart.
+//
+// Like the schema inferred for the CRD, the structs are conservative: every
+// struct preserves unknown fields, scalars are pointers so that explicitly
+// set zero values are not dropped, and values whose type can not be inferred
+// (null values, empty objects, floats and heterogeneous arrays) are typed as
+// apiextensionsv1.JSON.
+type specGenerator struct {
+	structs []*goStruct
+	// names holds the type names already in use in the package
+	names map[string]bool
+	// usesJSON is true if any generated field is typed as apiextensionsv1.JSON
+	usesJSON bool
+}
+
+// newSpecGenerator returns a specGenerator whose type names do not collide
+// with the other types scaffolded for kind.
+func newSpecGenerator(kind string) *specGenerator {
+	return &specGenerator{
+		names: map[string]bool{
+			kind:            true,
+			kind + "List":   true,
+			kind + "Status": true,
+		},
+	}
+}
+
+// generate adds the struct named name, and the structs of its nested objects,
+// for values. Nested structs are named after prefix and the field they type.
+func (g *specGenerator) generate(name, prefix, doc string, values map[string]interface{}) {
+	s := &goStruct{name: name, doc: doc}
+	g.names[name] = true
+	g.structs = append(g.structs, s)
+
+	type nestedStruct struct {
+		name, key string
+		values    map[string]interface{}
+	}
+	nestedStructs := []nestedStruct{}
+
+	fieldNames := map[string]bool{}
+	for _, key := range chartvalues.SortedKeys(values) {
+		// Keys that can not be expressed in a json tag are left to the
+		// preserved unknown fields of the struct.
+		if !isValidTag(key) {
+			continue
+		}
+		f := goField{name: uniqueName(goIdentifier(key), fieldNames), key: key}
+		fieldNames[f.name] = true
+
+		switch v := values[key].(type) {
+		case map[string]interface{}:
+			if len(v) == 0 {
+				f.goType = g.jsonType(true)
+				break
+			}
+			nested := uniqueName(prefix+f.name, g.names)
+			g.names[nested] = true
+			f.goType = "*" + nested
+			nestedStructs = append(nestedStructs, nestedStruct{name: nested, key: key, values: v})
+		case []interface{}:
+			if typ := goTypes[chartvalues.ItemsType(v)]; typ != "" {
+				f.goType = "[]" + typ
+			} else {
+				f.goType = "[]" + g.jsonType(false)
+			}
+		default:
+			if typ := goTypes[chartvalues.ScalarType(v)]; typ != "" {
+				f.goType = "*" + typ
+				f.markers = append(f.markers, "kubebuilder:default="+defaultValue(v))
+			} else {
+				f.goType = g.jsonType(true)
+			}
+		}
+		f.markers = append(f.markers, "optional")
+		s.fields = append(s.fields, f)
+	}
+
+	for _, n := range nestedStructs {
+		g.generate(n.name, n.name, fmt.Sprintf("%s mirrors the %q chart value", n.name, n.key), n.values)
+	}
+}
+
+func (g *specGenerator) jsonType(pointer bool) string {
+	g.usesJSON = true
+	if pointer {
+		return "*" + jsonType
+	}
+	return jsonType
+}
+
+// source returns the Go source of the generated structs.
+func (g *specGenerator) source() string {
+	b := &strings.Builder{}
+	for i, s := range g.structs {
+		if i != 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("//+kubebuilder:pruning:PreserveUnknownFields\n\n")
+		fmt.Fprintf(b, "// %s\n", s.doc)
+		fmt.Fprintf(b, "type %s struct {\n", s.name)
+		for j, f := range s.fields {
+			if j != 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "\t// %s mirrors the %q chart value\n", f.name, f.key)
+			for _, m := range f.markers {
+				fmt.Fprintf(b, "\t//+%s\n", m)
+			}
+			fmt.Fprintf(b, "\t%s %s `json:\"%s,omitempty\"`\n", f.name, f.goType, f.key)
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// goIdentifier returns an exported Go identifier for the chart value key.
+func goIdentifier(key string) string {
+	b := &strings.Builder{}
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "Value" + name
+	}
+	return name
+}
+
+// isValidTag returns true if key can be used as the name in a json tag, using
+// the same rules as encoding/json.
+func isValidTag(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueName returns name, with a numeric suffix if it is already in use.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	return unique
+}
+
+// goTypes are the Go types of the structural types of the scalar values. Floats are discouraged in
+// Kubernetes APIs and need a dangerous controller-gen option, so only integral numbers are typed.
+var goTypes = map[string]string{
+	chartvalues.String:  "string",
+	chartvalues.Boolean: "bool",
+	chartvalues.Integer: "int64",
+}
+
+// defaultValue returns the scalar v formatted as the value of a kubebuilder:default marker.
+func defaultValue(v interface{}) string {
+	switch n := v.(type) {
+	case string:
+		return strconv.Quote(n)
+	case float64:
+		return strconv.FormatInt(int64(n), 10)
+	}
+	return fmt.Sprint(v)
+}
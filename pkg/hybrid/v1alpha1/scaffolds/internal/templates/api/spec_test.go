@@ -0,0 +1,227 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"go/format"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestSpecGenerator(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   string
+		want     string
+		wantJSON bool
+	}{
+		{
+			name:   "no values",
+			values: "",
+			want: `//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedSpec mirrors the values
+type MemcachedSpec struct {
+}
+`,
+		},
+		{
+			name:   "scalars",
+			values: "name: memcached\nreplicaCount: 3\nenabled: true\nratio: 0.5\n",
+			want: `//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedSpec mirrors the values
+type MemcachedSpec struct {
+	// Enabled mirrors the "enabled" chart value
+	//+kubebuilder:default=true
+	//+optional
+	Enabled *bool ` + "`" + `json:"enabled,omitempty"` + "`" + `
+
+	// Name mirrors the "name" chart value
+	//+kubebuilder:default="memcached"
+	//+optional
+	Name *string ` + "`" + `json:"name,omitempty"` + "`" + `
+
+	// Ratio mirrors the "ratio" chart value
+	//+optional
+	Ratio *apiextensionsv1.JSON ` + "`" + `json:"ratio,omitempty"` + "`" + `
+
+	// ReplicaCount mirrors the "replicaCount" chart value
+	//+kubebuilder:default=3
+	//+optional
+	ReplicaCount *int64 ` + "`" + `json:"replicaCount,omitempty"` + "`" + `
+}
+`,
+			wantJSON: true,
+		},
+		{
+			name:   "lists, null values and empty objects",
+			values: "args: [--verbose]\nports: [80, 443]\nmixed: [a, 1]\nempty: []\nweights: [0.5]\ntolerations:\nresources: {}\n",
+			want: `//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedSpec mirrors the values
+type MemcachedSpec struct {
+	// Args mirrors the "args" chart value
+	//+optional
+	Args []string ` + "`" + `json:"args,omitempty"` + "`" + `
+
+	// Empty mirrors the "empty" chart value
+	//+optional
+	Empty []apiextensionsv1.JSON ` + "`" + `json:"empty,omitempty"` + "`" + `
+
+	// Mixed mirrors the "mixed" chart value
+	//+optional
+	Mixed []apiextensionsv1.JSON ` + "`" + `json:"mixed,omitempty"` + "`" + `
+
+	// Ports mirrors the "ports" chart value
+	//+optional
+	Ports []int64 ` + "`" + `json:"ports,omitempty"` + "`" + `
+
+	// Resources mirrors the "resources" chart value
+	//+optional
+	Resources *apiextensionsv1.JSON ` + "`" + `json:"resources,omitempty"` + "`" + `
+
+	// Tolerations mirrors the "tolerations" chart value
+	//+optional
+	Tolerations *apiextensionsv1.JSON ` + "`" + `json:"tolerations,omitempty"` + "`" + `
+
+	// Weights mirrors the "weights" chart value
+	//+optional
+	Weights []apiextensionsv1.JSON ` + "`" + `json:"weights,omitempty"` + "`" + `
+}
+`,
+			wantJSON: true,
+		},
+		{
+			name:   "nested objects",
+			values: "image:\n  repository: memcached\n  pull:\n    policy: Always\nservice:\n  port: 11211\n",
+			want: `//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedSpec mirrors the values
+type MemcachedSpec struct {
+	// Image mirrors the "image" chart value
+	//+optional
+	Image *MemcachedImage ` + "`" + `json:"image,omitempty"` + "`" + `
+
+	// Service mirrors the "service" chart value
+	//+optional
+	Service *MemcachedService ` + "`" + `json:"service,omitempty"` + "`" + `
+}
+
+//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedImage mirrors the "image" chart value
+type MemcachedImage struct {
+	// Pull mirrors the "pull" chart value
+	//+optional
+	Pull *MemcachedImagePull ` + "`" + `json:"pull,omitempty"` + "`" + `
+
+	// Repository mirrors the "repository" chart value
+	//+kubebuilder:default="memcached"
+	//+optional
+	Repository *string ` + "`" + `json:"repository,omitempty"` + "`" + `
+}
+
+//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedImagePull mirrors the "pull" chart value
+type MemcachedImagePull struct {
+	// Policy mirrors the "policy" chart value
+	//+kubebuilder:default="Always"
+	//+optional
+	Policy *string ` + "`" + `json:"policy,omitempty"` + "`" + `
+}
+
+//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedService mirrors the "service" chart value
+type MemcachedService struct {
+	// Port mirrors the "port" chart value
+	//+kubebuilder:default=11211
+	//+optional
+	Port *int64 ` + "`" + `json:"port,omitempty"` + "`" + `
+}
+`,
+		},
+		{
+			name:   "names that collide",
+			values: "status:\n  ready: true\nfoo-bar: a\nfooBar: b\n1st: c\n\"a,b\": d\n",
+			want: `//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedSpec mirrors the values
+type MemcachedSpec struct {
+	// Value1st mirrors the "1st" chart value
+	//+kubebuilder:default="c"
+	//+optional
+	Value1st *string ` + "`" + `json:"1st,omitempty"` + "`" + `
+
+	// FooBar mirrors the "foo-bar" chart value
+	//+kubebuilder:default="a"
+	//+optional
+	FooBar *string ` + "`" + `json:"foo-bar,omitempty"` + "`" + `
+
+	// FooBar2 mirrors the "fooBar" chart value
+	//+kubebuilder:default="b"
+	//+optional
+	FooBar2 *string ` + "`" + `json:"fooBar,omitempty"` + "`" + `
+
+	// Status mirrors the "status" chart value
+	//+optional
+	Status *MemcachedStatus2 ` + "`" + `json:"status,omitempty"` + "`" + `
+}
+
+//+kubebuilder:pruning:PreserveUnknownFields
+
+// MemcachedStatus2 mirrors the "status" chart value
+type MemcachedStatus2 struct {
+	// Ready mirrors the "ready" chart value
+	//+kubebuilder:default=true
+	//+optional
+	Ready *bool ` + "`" + `json:"ready,omitempty"` + "`" + `
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Parse the values as Helm does
+			values := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tt.values), &values); err != nil {
+				t.Fatal(err)
+			}
+
+			g := newSpecGenerator("Memcached")
+			g.generate("MemcachedSpec", "Memcached", "MemcachedSpec mirrors the values", values)
+			src := g.source()
+			if src != tt.want {
+				t.Errorf("expected source:\n%s\ngot:\n%s", tt.want, src)
+			}
+			if g.usesJSON != tt.wantJSON {
+				t.Errorf("expected usesJSON %v, got %v", tt.wantJSON, g.usesJSON)
+			}
+
+			// The types must be valid Go, formatted as go fmt does
+			file := "package v1alpha1\n\n" + src
+			formatted, err := format.Source([]byte(file))
+			if err != nil {
+				t.Fatalf("invalid Go source: %v", err)
+			}
+			if string(formatted) != file {
+				t.Errorf("expected formatted source:\n%s\ngot:\n%s", formatted, file)
+			}
+		})
+	}
+}
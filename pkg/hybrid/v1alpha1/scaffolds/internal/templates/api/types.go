@@ -0,0 +1,152 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Types{}
+
+// Types scaffolds the file that defines the Go types of a kind. The spec of
+// chart-backed kinds mirrors the default values of the chart
+type Types struct {
+	machinery.TemplateMixin
+	machinery.MultiGroupMixin
+	machinery.BoilerplateMixin
+	machinery.ResourceMixin
+
+	// Chart is the chart reconciled for Resource, if any
+	Chart *chart.Chart
+
+	// SpecTypes is the Go source of the spec struct and its nested structs
+	SpecTypes string
+	// UsesJSON is true if SpecTypes refers to apiextensionsv1.JSON
+	UsesJSON bool
+
+	Force bool
+}
+
+// SetTemplateDefaults implements file.Template
+func (f *Types) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join(apiDir(f.MultiGroup, f.Resource.Group), "%[kind]_types.go")
+	}
+	f.Path = f.Resource.Replacer().Replace(f.Path)
+
+	f.TemplateBody = typesTemplate
+
+	if f.Force {
+		f.IfExistsAction = machinery.OverwriteFile
+	} else {
+		f.IfExistsAction = machinery.Error
+	}
+
+	if f.Chart == nil {
+		f.SpecTypes = fmt.Sprintf(defaultSpecTypes, f.Resource.Kind, strings.ToLower(f.Resource.Kind))
+		return nil
+	}
+
+	g := newSpecGenerator(f.Resource.Kind)
+	g.generate(f.Resource.Kind+"Spec", f.Resource.Kind,
+		fmt.Sprintf("%sSpec defines the desired state of %s, it mirrors the values of the %q chart",
+			f.Resource.Kind, f.Resource.Kind, f.Chart.Name()),
+		f.Chart.Values)
+	f.SpecTypes = g.source()
+	f.UsesJSON = g.usesJSON
+
+	return nil
+}
+
+// defaultSpecTypes is the spec of kinds that are not backed by a chart
+const defaultSpecTypes = `// %[1]sSpec defines the desired state of %[1]s
+type %[1]sSpec struct {
+	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+
+	// Foo is an example field of %[1]s. Edit %[2]s_types.go to remove/update
+	Foo string ` + "`" + `json:"foo,omitempty"` + "`" + `
+}
+`
+
+const typesTemplate = `{{ .Boilerplate }}
+
+package {{ .Resource.Version }}
+
+import (
+{{- if .UsesJSON }}
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+{{- end }}
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+{{- if .Chart }}
+// The spec is passed to the chart as its values, keep the fields in sync with the values of the chart.
+{{- end }}
+
+{{ .SpecTypes }}
+{{ if .Chart -}}
+//+kubebuilder:pruning:PreserveUnknownFields
+
+// {{ .Resource.Kind }}Status defines the observed state of {{ .Resource.Kind }}, as reported by the Helm reconciler
+{{ else -}}
+// {{ .Resource.Kind }}Status defines the observed state of {{ .Resource.Kind }}
+{{ end -}}
+type {{ .Resource.Kind }}Status struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+{{- if and (not .Resource.API.Namespaced) (not .Resource.IsRegularPlural) }}
+//+kubebuilder:resource:path={{ .Resource.Plural }},scope=Cluster
+{{- else if not .Resource.API.Namespaced }}
+//+kubebuilder:resource:scope=Cluster
+{{- else if not .Resource.IsRegularPlural }}
+//+kubebuilder:resource:path={{ .Resource.Plural }}
+{{- end }}
+
+// {{ .Resource.Kind }} is the Schema for the {{ .Resource.Plural }} API
+type {{ .Resource.Kind }} struct {
+	metav1.TypeMeta   ` + "`" + `json:",inline"` + "`" + `
+	metav1.ObjectMeta ` + "`" + `json:"metadata,omitempty"` + "`" + `
+
+	Spec   {{ .Resource.Kind }}Spec   ` + "`" + `json:"spec,omitempty"` + "`" + `
+	Status {{ .Resource.Kind }}Status ` + "`" + `json:"status,omitempty"` + "`" + `
+}
+
+//+kubebuilder:object:root=true
+
+// {{ .Resource.Kind }}List contains a list of {{ .Resource.Kind }}
+type {{ .Resource.Kind }}List struct {
+	metav1.TypeMeta ` + "`" + `json:",inline"` + "`" + `
+	metav1.ListMeta ` + "`" + `json:"metadata,omitempty"` + "`" + `
+	Items           []{{ .Resource.Kind }} ` + "`" + `json:"items"` + "`" + `
+}
+
+func init() {
+	SchemeBuilder.Register(&{{ .Resource.Kind }}{}, &{{ .Resource.Kind }}List{})
+}
+`
@@ -0,0 +1,140 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chart"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &CRD{}
+
+// CRD scaffolds a CustomResourceDefinition manifest for a chart-backed kind
+type CRD struct {
+	machinery.TemplateMixin
+	machinery.ResourceMixin
+
+	// Chart is the chart reconciled for Resource. If it has a values schema,
+	// the schema is used for the spec of the CRD
+	Chart *chart.Chart
+
+	// InferSchema indicates whether the schema of the spec is inferred from
+	// the default values of Chart when it has no values schema
+	InferSchema bool
+
+	// SpecSchema is the indented YAML of the schema of the spec
+	SpecSchema string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *CRD) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "crd", "bases",
+			fmt.Sprintf("%s_%s.yaml", f.Resource.QualifiedGroup(), f.Resource.Plural))
+	}
+
+	f.TemplateBody = crdTemplate
+
+	f.IfExistsAction = machinery.OverwriteFile
+
+	specSchema, err := indentedYAML(f.specSchema(), 12)
+	if err != nil {
+		return err
+	}
+	f.SpecSchema = specSchema
+
+	return nil
+}
+
+// specSchema returns the schema of the spec, derived from the values schema of
+// the chart if possible, or inferred from its default values if requested.
+// Otherwise the spec preserves unknown fields.
+func (f *CRD) specSchema() apiextv1.JSONSchemaProps {
+	props := apiextv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: boolPtr(true),
+	}
+	switch {
+	case f.Chart == nil:
+	case len(f.Chart.Schema) != 0:
+		if schemaProps, err := specSchemaFromJSONSchema(f.Chart.Schema, f.Chart.Values); err != nil {
+			log.Warnf("Using a schema without validation for the spec of %s: failed to convert the values "+
+				"schema of chart %q: %s", f.Resource.Kind, f.Chart.Name(), err)
+		} else {
+			props = schemaProps
+		}
+	case f.InferSchema:
+		if schemaProps, err := specSchemaFromValues(f.Chart.Values); err != nil {
+			log.Warnf("Using a schema without validation for the spec of %s: failed to infer a schema "+
+				"from the values of chart %q: %s", f.Resource.Kind, f.Chart.Name(), err)
+		} else {
+			props = schemaProps
+		}
+	}
+
+	if props.Description == "" {
+		props.Description = fmt.Sprintf("Spec defines the desired state of %s", f.Resource.Kind)
+	}
+	return props
+}
+
+const crdTemplate = `---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: {{ .Resource.Plural }}.{{ .Resource.QualifiedGroup }}
+spec:
+  group: {{ .Resource.QualifiedGroup }}
+  names:
+    kind: {{ .Resource.Kind }}
+    listKind: {{ .Resource.Kind }}List
+    plural: {{ .Resource.Plural }}
+    singular: {{ .Resource.Kind | lower }}
+  scope: {{ if .Resource.API.Namespaced }}Namespaced{{ else }}Cluster{{ end }}
+  versions:
+  - name: {{ .Resource.Version }}
+    schema:
+      openAPIV3Schema:
+        description: {{ .Resource.Kind }} is the Schema for the {{ .Resource.Plural }} API
+        properties:
+          apiVersion:
+            description: 'APIVersion defines the versioned schema of this representation
+              of an object. Servers should convert recognized schemas to the latest
+              internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources'
+            type: string
+          kind:
+            description: 'Kind is a string value representing the REST resource this
+              object represents. Servers may infer this from the endpoint the client
+              submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds'
+            type: string
+          metadata:
+            type: object
+          spec:
+{{ .SpecSchema }}
+          status:
+            description: Status defines the observed state of {{ .Resource.Kind }}
+            type: object
+            x-kubernetes-preserve-unknown-fields: true
+        type: object
+    served: true
+    storage: true
+    subresources:
+      status: {}
+`
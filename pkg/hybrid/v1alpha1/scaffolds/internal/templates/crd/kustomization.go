@@ -0,0 +1,131 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Kustomization{}
+var _ machinery.Inserter = &Kustomization{}
+
+// Kustomization scaffolds the kustomization file in the crd folder
+type Kustomization struct {
+	machinery.TemplateMixin
+	machinery.ResourceMixin
+
+	// Conversion indicates whether the patches enabling the conversion webhook
+	// of the CRD of Resource are added
+	Conversion bool
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Kustomization) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "crd", "kustomization.yaml")
+	}
+	if f.Resource != nil {
+		f.Path = f.Resource.Replacer().Replace(f.Path)
+	}
+
+	f.TemplateBody = fmt.Sprintf(kustomizationTemplate,
+		machinery.NewMarkerFor(f.Path, resourceMarker),
+		machinery.NewMarkerFor(f.Path, webhookPatchMarker),
+		machinery.NewMarkerFor(f.Path, caInjectionPatchMarker),
+		machinery.NewMarkerFor(f.Path, configurationMarker),
+	)
+
+	return nil
+}
+
+const (
+	resourceMarker         = "crdkustomizeresource"
+	webhookPatchMarker     = "crdkustomizeconversionpatch"
+	caInjectionPatchMarker = "crdkustomizeconversioncainjection"
+	configurationMarker    = "crdkustomizeconfiguration"
+)
+
+// GetMarkers implements machinery.Inserter
+func (f *Kustomization) GetMarkers() []machinery.Marker {
+	return []machinery.Marker{
+		machinery.NewMarkerFor(f.Path, resourceMarker),
+		machinery.NewMarkerFor(f.Path, webhookPatchMarker),
+		machinery.NewMarkerFor(f.Path, caInjectionPatchMarker),
+		machinery.NewMarkerFor(f.Path, configurationMarker),
+	}
+}
+
+const (
+	resourceCodeFragment = `- bases/%s_%s.yaml
+`
+	webhookPatchCodeFragment = `- patches/webhook_in_%s.yaml
+`
+	caInjectionPatchCodeFragment = `- patches/cainjection_in_%s.yaml
+`
+	configurationCodeFragment = `- kustomizeconfig.yaml
+`
+)
+
+// GetCodeFragments implements machinery.Inserter
+func (f *Kustomization) GetCodeFragments() machinery.CodeFragmentsMap {
+	fragments := make(machinery.CodeFragmentsMap, 4)
+
+	// If resource is not being provided we are creating the file, not updating it
+	if f.Resource == nil {
+		return fragments
+	}
+
+	// Generate resource code fragments
+	res := make([]string, 0)
+	res = append(res, fmt.Sprintf(resourceCodeFragment, f.Resource.QualifiedGroup(), f.Resource.Plural))
+
+	// Only store code fragments in the map if the slices are non-empty
+	if len(res) != 0 {
+		fragments[machinery.NewMarkerFor(f.Path, resourceMarker)] = res
+	}
+
+	if f.Conversion {
+		fragments[machinery.NewMarkerFor(f.Path, webhookPatchMarker)] = []string{
+			fmt.Sprintf(webhookPatchCodeFragment, f.Resource.Plural),
+		}
+		fragments[machinery.NewMarkerFor(f.Path, caInjectionPatchMarker)] = []string{
+			fmt.Sprintf(caInjectionPatchCodeFragment, f.Resource.Plural),
+		}
+		fragments[machinery.NewMarkerFor(f.Path, configurationMarker)] = []string{configurationCodeFragment}
+	}
+
+	return fragments
+}
+
+const kustomizationTemplate = `# This kustomization.yaml is not intended to be run by itself,
+# since it depends on service name and namespace that are out of this kustomize package.
+# It should be run by config/default
+resources:
+%s
+
+# Patches enabling the conversion webhooks of the CRDs, added by "create webhook --conversion"
+patchesStrategicMerge:
+%s
+
+# Patches injecting the CA of the conversion webhooks into the CRDs, requires cert-manager
+%s
+
+# Teaches kustomize how to substitute the webhook service and the CA in the CRDs
+configurations:
+%s
+`
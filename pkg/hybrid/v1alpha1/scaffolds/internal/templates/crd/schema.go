@@ -0,0 +1,389 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/chartvalues"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// supportedFormats are the JSON schema formats understood by the Kubernetes API server.
+var supportedFormats = map[string]struct{}{
+	"bsonobjectid": {}, "byte": {}, "cidr": {}, "creditcard": {}, "date": {}, "date-time": {},
+	"datetime": {}, "double": {}, "duration": {}, "email": {}, "float": {}, "hexcolor": {},
+	"hostname": {}, "int32": {}, "int64": {}, "ipv4": {}, "ipv6": {}, "isbn": {}, "isbn10": {},
+	"isbn13": {}, "mac": {}, "password": {}, "rgbcolor": {}, "ssn": {}, "uri": {}, "uuid": {},
+	"uuid3": {}, "uuid4": {}, "uuid5": {},
+}
+
+// specSchemaFromJSONSchema converts the JSON schema of the values of a chart
+// into a structural schema for the spec of a CRD. values are the default values
+// of the chart.
+//
+// The conversion is conservative: nodes that can not be expressed as a
+// structural schema, such as nodes without a type or combining several
+// types, preserve unknown fields instead of being validated, and objects only
+// prune unknown fields if the JSON schema forbids additional properties.
+//
+// Helm validates the values schema against the values of a release merged with
+// the default values, while the API server validates the spec on its own. So
+// properties with a default value are not required in the spec.
+func specSchemaFromJSONSchema(data []byte, values map[string]interface{}) (apiextv1.JSONSchemaProps, error) {
+	root := map[string]interface{}{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return apiextv1.JSONSchemaProps{}, fmt.Errorf("failed to parse values schema: %v", err)
+	}
+
+	c := &jsonSchemaConverter{root: root, resolving: map[string]bool{}}
+	props, err := c.convert(root, values)
+	if err != nil {
+		return apiextv1.JSONSchemaProps{}, err
+	}
+	if props.Type != "object" {
+		return apiextv1.JSONSchemaProps{}, fmt.Errorf("values schema must describe an object, found %q", props.Type)
+	}
+	return props, nil
+}
+
+// jsonSchemaConverter converts JSON schema nodes into structural schemas.
+type jsonSchemaConverter struct {
+	// root is the JSON schema document, used to resolve references
+	root map[string]interface{}
+	// resolving holds the references being resolved, to break reference cycles
+	resolving map[string]bool
+}
+
+// convert converts node, whose default value is defaults if any.
+func (c *jsonSchemaConverter) convert(node map[string]interface{}, defaults interface{}) (apiextv1.JSONSchemaProps,
+	error) {
+	if ref, ok := node["$ref"].(string); ok {
+		if c.resolving[ref] {
+			return preserveUnknownFields(node), nil
+		}
+		target, err := c.resolve(ref)
+		if err != nil {
+			return apiextv1.JSONSchemaProps{}, err
+		}
+		c.resolving[ref] = true
+		defer delete(c.resolving, ref)
+		return c.convert(target, defaults)
+	}
+
+	props := apiextv1.JSONSchemaProps{Description: description(node)}
+	switch typ := nodeType(node); typ {
+	case "":
+		return preserveUnknownFields(node), nil
+	case "int-or-string":
+		props.XIntOrString = true
+	default:
+		props.Type = typ
+	}
+	props.Nullable = isNullable(node)
+
+	if err := setValueValidations(&props, node); err != nil {
+		return apiextv1.JSONSchemaProps{}, err
+	}
+
+	switch props.Type {
+	case "object":
+		defaultValues, _ := defaults.(map[string]interface{})
+		if err := c.convertObject(&props, node, defaultValues); err != nil {
+			return apiextv1.JSONSchemaProps{}, err
+		}
+	case "array":
+		if err := c.convertArray(&props, node); err != nil {
+			return apiextv1.JSONSchemaProps{}, err
+		}
+	}
+	return props, nil
+}
+
+// convertObject converts the properties of the object node, whose default value is defaults if any.
+func (c *jsonSchemaConverter) convertObject(props *apiextv1.JSONSchemaProps, node,
+	defaults map[string]interface{}) error {
+	properties, _ := node["properties"].(map[string]interface{})
+	for _, name := range chartvalues.SortedKeys(properties) {
+		child, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		childProps, err := c.convert(child, defaults[name])
+		if err != nil {
+			return fmt.Errorf("property %q: %v", name, err)
+		}
+		if props.Properties == nil {
+			props.Properties = map[string]apiextv1.JSONSchemaProps{}
+		}
+		props.Properties[name] = childProps
+	}
+
+	// A null default value removes the value in Helm, it is not a default
+	if required, ok := node["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok && defaults[name] == nil {
+				if _, found := props.Properties[name]; found {
+					props.Required = append(props.Required, name)
+				}
+			}
+		}
+	}
+
+	if v, ok := chartvalues.IntValue(node["minProperties"]); ok {
+		props.MinProperties = &v
+	}
+	if v, ok := chartvalues.IntValue(node["maxProperties"]); ok {
+		props.MaxProperties = &v
+	}
+
+	// Properties and additionalProperties are mutually exclusive in structural
+	// schemas. Unknown fields are only pruned when the JSON schema explicitly
+	// forbids them, since Helm charts commonly accept values not described in
+	// their schema.
+	switch additional := node["additionalProperties"].(type) {
+	case bool:
+		if additional {
+			props.XPreserveUnknownFields = boolPtr(true)
+		}
+	case map[string]interface{}:
+		if len(props.Properties) != 0 {
+			props.XPreserveUnknownFields = boolPtr(true)
+			break
+		}
+		additionalProps, err := c.convert(additional, nil)
+		if err != nil {
+			return fmt.Errorf("additionalProperties: %v", err)
+		}
+		props.AdditionalProperties = &apiextv1.JSONSchemaPropsOrBool{Allows: true, Schema: &additionalProps}
+	default:
+		props.XPreserveUnknownFields = boolPtr(true)
+	}
+	return nil
+}
+
+func (c *jsonSchemaConverter) convertArray(props *apiextv1.JSONSchemaProps, node map[string]interface{}) error {
+	items, ok := node["items"].(map[string]interface{})
+	if !ok {
+		// Arrays without items, or with a list of items, can not be described
+		// by a single structural schema.
+		items = map[string]interface{}{}
+	}
+	// Helm replaces arrays instead of merging them, so their items have no default value
+	itemProps, err := c.convert(items, nil)
+	if err != nil {
+		return fmt.Errorf("items: %v", err)
+	}
+	props.Items = &apiextv1.JSONSchemaPropsOrArray{Schema: &itemProps}
+
+	if v, ok := chartvalues.IntValue(node["minItems"]); ok {
+		props.MinItems = &v
+	}
+	if v, ok := chartvalues.IntValue(node["maxItems"]); ok {
+		props.MaxItems = &v
+	}
+	return nil
+}
+
+// resolve returns the node referenced by ref, which must be local to the document.
+func (c *jsonSchemaConverter) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %q: only references local to the schema are supported", ref)
+	}
+
+	var node interface{} = c.root
+	for _, token := range strings.Split(strings.TrimPrefix(strings.TrimPrefix(ref, "#"), "/"), "/") {
+		if token == "" {
+			continue
+		}
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("failed to resolve reference %q", ref)
+		}
+		if node, ok = m[token]; !ok {
+			return nil, fmt.Errorf("failed to resolve reference %q", ref)
+		}
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("reference %q does not point to a schema", ref)
+	}
+	return m, nil
+}
+
+// nodeType returns the structural type of node, "int-or-string" if node can be
+// either an integer or a string, or "" if it can not be determined.
+func nodeType(node map[string]interface{}) string {
+	var types []string
+	switch t := node["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	nonNull := []string{}
+	for _, t := range types {
+		if t != "null" {
+			nonNull = append(nonNull, t)
+		}
+	}
+	sort.Strings(nonNull)
+
+	switch {
+	case len(nonNull) == 1:
+		return nonNull[0]
+	case len(nonNull) == 2 && nonNull[0] == "integer" && nonNull[1] == "string":
+		return "int-or-string"
+	case len(nonNull) == 0 && len(types) == 0:
+		if _, ok := node["properties"]; ok {
+			return "object"
+		}
+		if _, ok := node["items"]; ok {
+			return "array"
+		}
+	}
+	return ""
+}
+
+// isNullable returns true if node explicitly allows null values.
+func isNullable(node map[string]interface{}) bool {
+	if types, ok := node["type"].([]interface{}); ok {
+		for _, t := range types {
+			if t == "null" {
+				return true
+			}
+		}
+	}
+	nullable, _ := node["nullable"].(bool)
+	return nullable
+}
+
+// setValueValidations copies the validations of node that apply to scalar values.
+func setValueValidations(props *apiextv1.JSONSchemaProps, node map[string]interface{}) error {
+	if v, ok := node["default"]; ok {
+		raw, err := rawJSON(v)
+		if err != nil {
+			return err
+		}
+		props.Default = raw
+	}
+
+	enum, _ := node["enum"].([]interface{})
+	if v, ok := node["const"]; ok {
+		enum = []interface{}{v}
+	}
+	for _, v := range enum {
+		raw, err := rawJSON(v)
+		if err != nil {
+			return err
+		}
+		props.Enum = append(props.Enum, *raw)
+	}
+
+	if format, ok := node["format"].(string); ok {
+		if _, supported := supportedFormats[format]; supported {
+			props.Format = format
+		}
+	}
+	if pattern, ok := node["pattern"].(string); ok {
+		props.Pattern = pattern
+	}
+	if v, ok := chartvalues.IntValue(node["minLength"]); ok {
+		props.MinLength = &v
+	}
+	if v, ok := chartvalues.IntValue(node["maxLength"]); ok {
+		props.MaxLength = &v
+	}
+	if v, ok := node["multipleOf"].(float64); ok {
+		props.MultipleOf = &v
+	}
+
+	if v, ok := node["minimum"].(float64); ok {
+		props.Minimum = &v
+	}
+	if v, ok := node["maximum"].(float64); ok {
+		props.Maximum = &v
+	}
+	// exclusiveMinimum and exclusiveMaximum are booleans up to draft 4, and numbers since draft 6.
+	switch v := node["exclusiveMinimum"].(type) {
+	case bool:
+		props.ExclusiveMinimum = v && props.Minimum != nil
+	case float64:
+		props.Minimum = &v
+		props.ExclusiveMinimum = true
+	}
+	switch v := node["exclusiveMaximum"].(type) {
+	case bool:
+		props.ExclusiveMaximum = v && props.Maximum != nil
+	case float64:
+		props.Maximum = &v
+		props.ExclusiveMaximum = true
+	}
+	return nil
+}
+
+// preserveUnknownFields returns a schema accepting any value for node.
+func preserveUnknownFields(node map[string]interface{}) apiextv1.JSONSchemaProps {
+	return apiextv1.JSONSchemaProps{
+		Description:            description(node),
+		XPreserveUnknownFields: boolPtr(true),
+	}
+}
+
+func description(node map[string]interface{}) string {
+	if d, ok := node["description"].(string); ok {
+		return d
+	}
+	d, _ := node["title"].(string)
+	return d
+}
+
+func rawJSON(v interface{}) (*apiextv1.JSON, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &apiextv1.JSON{Raw: b}, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// indentedYAML returns the YAML of props with every line indented by indent
+// spaces, so that it can be embedded in a template.
+func indentedYAML(props apiextv1.JSONSchemaProps, indent int) (string, error) {
+	b, err := yaml.Marshal(props)
+	if err != nil {
+		return "", err
+	}
+	pad := strings.Repeat(" ", indent)
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = pad + line
+	}
+	return strings.Join(lines, "\n"), nil
+}
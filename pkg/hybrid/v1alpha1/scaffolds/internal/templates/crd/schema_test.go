@@ -0,0 +1,353 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"strings"
+	"testing"
+
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+// checkSchema checks that props marshals to the YAML want.
+func checkSchema(t *testing.T, props apiextv1.JSONSchemaProps, want string) {
+	b, err := yaml.Marshal(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != want {
+		t.Errorf("expected schema:\n%s\ngot:\n%s", want, b)
+	}
+}
+
+func TestSpecSchemaFromJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		values  map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{
+			name: "local reference",
+			schema: `{
+  "type": "object",
+  "properties": {"image": {"$ref": "#/definitions/image"}},
+  "additionalProperties": false,
+  "definitions": {
+    "image": {
+      "type": "object",
+      "description": "The image of the operand",
+      "properties": {"tag": {"type": "string"}},
+      "additionalProperties": false
+    }
+  }
+}`,
+			want: `properties:
+  image:
+    description: The image of the operand
+    properties:
+      tag:
+        type: string
+    type: object
+type: object
+`,
+		},
+		{
+			name: "escaped reference",
+			schema: `{
+  "type": "object",
+  "properties": {"port": {"$ref": "#/definitions/a~1b~0c"}},
+  "additionalProperties": false,
+  "definitions": {"a/b~c": {"type": "integer", "minimum": 1}}
+}`,
+			want: `properties:
+  port:
+    minimum: 1
+    type: integer
+type: object
+`,
+		},
+		{
+			name: "reference cycle",
+			schema: `{
+  "type": "object",
+  "properties": {"tree": {"$ref": "#/definitions/node"}},
+  "additionalProperties": false,
+  "definitions": {
+    "node": {
+      "type": "object",
+      "properties": {"child": {"$ref": "#/definitions/node"}},
+      "additionalProperties": false
+    }
+  }
+}`,
+			want: `properties:
+  tree:
+    properties:
+      child:
+        x-kubernetes-preserve-unknown-fields: true
+    type: object
+type: object
+`,
+		},
+		{
+			name:    "remote reference",
+			schema:  `{"type": "object", "properties": {"image": {"$ref": "https://example.com/image.json"}}}`,
+			wantErr: `property "image": unsupported reference "https://example.com/image.json"`,
+		},
+		{
+			name:    "missing reference",
+			schema:  `{"type": "object", "properties": {"image": {"$ref": "#/definitions/image"}}}`,
+			wantErr: `property "image": failed to resolve reference "#/definitions/image"`,
+		},
+		{
+			name: "oneOf and anyOf",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "resources": {"oneOf": [{"type": "string"}, {"type": "object"}]},
+    "replicas": {"anyOf": [{"type": "integer"}, {"type": "string"}]},
+    "mode": {"type": "string", "anyOf": [{"enum": ["a"]}, {"enum": ["b"]}]}
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  mode:
+    type: string
+  replicas:
+    x-kubernetes-preserve-unknown-fields: true
+  resources:
+    x-kubernetes-preserve-unknown-fields: true
+type: object
+`,
+		},
+		{
+			name: "several types",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "port": {"type": ["integer", "string"]},
+    "value": {"type": ["boolean", "string"]}
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  port:
+    x-kubernetes-int-or-string: true
+  value:
+    x-kubernetes-preserve-unknown-fields: true
+type: object
+`,
+		},
+		{
+			name: "additionalProperties",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "labels": {"type": "object", "additionalProperties": {"type": "string"}},
+    "annotations": {"type": "object", "additionalProperties": true},
+    "env": {"type": "object"},
+    "affinity": {
+      "type": "object",
+      "properties": {"zone": {"type": "string"}},
+      "additionalProperties": {"type": "string"}
+    }
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  affinity:
+    properties:
+      zone:
+        type: string
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  annotations:
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  env:
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  labels:
+    additionalProperties:
+      type: string
+    type: object
+type: object
+`,
+		},
+		{
+			name: "nullable",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "name": {"type": ["string", "null"]},
+    "tag": {"type": "string", "nullable": true},
+    "nothing": {"type": "null"},
+    "items": {"type": ["array", "null"], "items": {"type": "integer"}, "maxItems": 3}
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  items:
+    items:
+      type: integer
+    maxItems: 3
+    nullable: true
+    type: array
+  name:
+    nullable: true
+    type: string
+  nothing:
+    x-kubernetes-preserve-unknown-fields: true
+  tag:
+    nullable: true
+    type: string
+type: object
+`,
+		},
+		{
+			name: "untyped nodes preserve unknown fields",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "extra": {"description": "Anything"},
+    "list": {"type": "array"},
+    "tuple": {"type": "array", "items": [{"type": "string"}, {"type": "integer"}]},
+    "inferred": {"properties": {"a": {"type": "boolean"}}, "additionalProperties": false}
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  extra:
+    description: Anything
+    x-kubernetes-preserve-unknown-fields: true
+  inferred:
+    properties:
+      a:
+        type: boolean
+    type: object
+  list:
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+  tuple:
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+`,
+		},
+		{
+			name: "required properties without a default value",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "image": {"type": "string"},
+    "replicas": {"type": "integer"},
+    "host": {"type": "string"}
+  },
+  "required": ["image", "replicas", "host", "missing"],
+  "additionalProperties": false
+}`,
+			values: map[string]interface{}{"image": "nginx", "host": nil},
+			want: `properties:
+  host:
+    type: string
+  image:
+    type: string
+  replicas:
+    type: integer
+required:
+- replicas
+- host
+type: object
+`,
+		},
+		{
+			name: "value validations",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "pullPolicy": {"type": "string", "enum": ["Always", "IfNotPresent"], "default": "Always"},
+    "version": {"type": "string", "const": "v1"},
+    "email": {"type": "string", "format": "email", "pattern": "^.+@.+$", "minLength": 3},
+    "color": {"type": "string", "format": "color"},
+    "ratio": {"type": "number", "exclusiveMinimum": 0, "maximum": 1, "exclusiveMaximum": true}
+  },
+  "additionalProperties": false
+}`,
+			want: `properties:
+  color:
+    type: string
+  email:
+    format: email
+    minLength: 3
+    pattern: ^.+@.+$
+    type: string
+  pullPolicy:
+    default: Always
+    enum:
+    - Always
+    - IfNotPresent
+    type: string
+  ratio:
+    exclusiveMaximum: true
+    exclusiveMinimum: true
+    maximum: 1
+    minimum: 0
+    type: number
+  version:
+    enum:
+    - v1
+    type: string
+type: object
+`,
+		},
+		{
+			name:    "root is not an object",
+			schema:  `{"type": "array", "items": {"type": "string"}}`,
+			wantErr: `values schema must describe an object, found "array"`,
+		},
+		{
+			name:    "invalid JSON",
+			schema:  `{"type": "object"`,
+			wantErr: "failed to parse values schema",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			props, err := specSchemaFromJSONSchema([]byte(tt.schema), tt.values)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr == "" {
+				checkSchema(t, props, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/chartvalues"
+	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// specSchemaFromValues infers a structural schema for the spec of a CRD from
+// the default values of a chart.
+//
+// The inference is conservative so that custom resources written against the
+// chart's documentation keep being accepted:
+//   - every object preserves unknown fields, since values are commonly added
+//     to a chart without a default
+//   - null values and empty objects, whose type can not be inferred, preserve
+//     unknown fields
+//   - arrays only type their items if every element has the same scalar type
+//
+// Scalars and arrays keep their value as default.
+func specSchemaFromValues(values map[string]interface{}) (apiextv1.JSONSchemaProps, error) {
+	return inferObject(values)
+}
+
+func inferSchema(value interface{}) (apiextv1.JSONSchemaProps, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return inferObject(v)
+	case []interface{}:
+		return inferArray(v)
+	case nil:
+		return apiextv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}, nil
+	}
+
+	typ := chartvalues.ScalarType(value)
+	if typ == "" {
+		return apiextv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}, nil
+	}
+	def, err := rawJSON(value)
+	if err != nil {
+		return apiextv1.JSONSchemaProps{}, err
+	}
+	return apiextv1.JSONSchemaProps{Type: typ, Default: def}, nil
+}
+
+func inferObject(values map[string]interface{}) (apiextv1.JSONSchemaProps, error) {
+	props := apiextv1.JSONSchemaProps{
+		Type:                   "object",
+		XPreserveUnknownFields: boolPtr(true),
+	}
+	for _, name := range chartvalues.SortedKeys(values) {
+		childProps, err := inferSchema(values[name])
+		if err != nil {
+			return apiextv1.JSONSchemaProps{}, err
+		}
+		if props.Properties == nil {
+			props.Properties = map[string]apiextv1.JSONSchemaProps{}
+		}
+		props.Properties[name] = childProps
+	}
+	return props, nil
+}
+
+func inferArray(values []interface{}) (apiextv1.JSONSchemaProps, error) {
+	items := apiextv1.JSONSchemaProps{XPreserveUnknownFields: boolPtr(true)}
+	if typ := chartvalues.ItemsType(values); typ != "" {
+		items = apiextv1.JSONSchemaProps{Type: typ}
+	}
+
+	def, err := rawJSON(values)
+	if err != nil {
+		return apiextv1.JSONSchemaProps{}, err
+	}
+	return apiextv1.JSONSchemaProps{
+		Type:    "array",
+		Items:   &apiextv1.JSONSchemaPropsOrArray{Schema: &items},
+		Default: def,
+	}, nil
+}
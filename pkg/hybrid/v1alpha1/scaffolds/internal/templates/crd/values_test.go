@@ -0,0 +1,194 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crd
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestSpecSchemaFromValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values string
+		want   string
+	}{
+		{
+			name:   "no values",
+			values: "",
+			want: `type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "scalars",
+			values: "name: memcached\nreplicas: 3\nratio: 0.5\nenabled: true\n",
+			want: `properties:
+  enabled:
+    default: true
+    type: boolean
+  name:
+    default: memcached
+    type: string
+  ratio:
+    default: 0.5
+    type: number
+  replicas:
+    default: 3
+    type: integer
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "null values",
+			values: "nodeSelector:\ntolerations: null\n",
+			want: `properties:
+  nodeSelector:
+    x-kubernetes-preserve-unknown-fields: true
+  tolerations:
+    x-kubernetes-preserve-unknown-fields: true
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "empty list",
+			values: "args: []\n",
+			want: `properties:
+  args:
+    default: []
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "list of a single type",
+			values: "ports: [80, 443]\n",
+			want: `properties:
+  ports:
+    default:
+    - 80
+    - 443
+    items:
+      type: integer
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "list of mixed types",
+			values: "args: [--port, 80]\n",
+			want: `properties:
+  args:
+    default:
+    - --port
+    - 80
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "list of integers and numbers",
+			values: "weights: [1, 1.5]\n",
+			want: `properties:
+  weights:
+    default:
+    - 1
+    - 1.5
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "list of objects",
+			values: "env:\n- name: FOO\n  value: bar\n",
+			want: `properties:
+  env:
+    default:
+    - name: FOO
+      value: bar
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "list with a null element",
+			values: "hosts: [example.com, null]\n",
+			want: `properties:
+  hosts:
+    default:
+    - example.com
+    - null
+    items:
+      x-kubernetes-preserve-unknown-fields: true
+    type: array
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+		{
+			name:   "nested maps",
+			values: "image:\n  repository: nginx\n  pull:\n    policy: Always\nresources: {}\n",
+			want: `properties:
+  image:
+    properties:
+      pull:
+        properties:
+          policy:
+            default: Always
+            type: string
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+      repository:
+        default: nginx
+        type: string
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+  resources:
+    type: object
+    x-kubernetes-preserve-unknown-fields: true
+type: object
+x-kubernetes-preserve-unknown-fields: true
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Parse the values as Helm does
+			values := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tt.values), &values); err != nil {
+				t.Fatal(err)
+			}
+			props, err := specSchemaFromValues(values)
+			checkErr(t, err, "")
+			checkSchema(t, props, tt.want)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+Modifications copyright 2021 The Operator-SDK Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package templates
+
+import (
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &Dockerfile{}
+var _ machinery.Template = &DockerIgnore{}
+
+// Dockerfile scaffolds a file that defines the containerized build process of the manager
+type Dockerfile struct {
+	machinery.TemplateMixin
+
+	// GoVersion is the version of the Go image building the manager
+	GoVersion string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Dockerfile) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = "Dockerfile"
+	}
+
+	f.TemplateBody = dockerfileTemplate
+
+	return nil
+}
+
+// The whole build context is copied since the api and controllers directories only
+// exist once a Go kind is created, .dockerignore keeps it to the files the image needs.
+const dockerfileTemplate = `# Build the manager binary
+FROM golang:{{ .GoVersion }} as builder
+
+WORKDIR /workspace
+# Copy the Go Modules manifests
+# go.sum is written by 'go mod tidy', run it before building the image if the project was initialized with --skip-deps
+COPY go.mod go.mod
+COPY go.sum go.sum
+# cache deps before building and copying source so that we don't need to re-download as much
+# and so that source changes don't invalidate our downloaded layer
+RUN go mod download
+
+# Copy the go source, the watches file and the helm charts
+COPY . .
+
+# Build
+RUN CGO_ENABLED=0 GOOS=linux GOARCH=amd64 go build -a -o manager main.go
+
+# Projects without Helm-backed kinds have no helm-charts directory
+RUN mkdir -p helm-charts
+
+# Use distroless as minimal base image to package the manager binary
+# Refer to https://github.com/GoogleContainerTools/distroless for more details
+FROM gcr.io/distroless/static:nonroot
+WORKDIR /
+COPY --from=builder /workspace/manager .
+COPY --from=builder /workspace/watches.yaml watches.yaml
+COPY --from=builder /workspace/helm-charts/ helm-charts/
+USER 65532:65532
+
+ENTRYPOINT ["/manager"]
+`
+
+// DockerIgnore scaffolds the file that excludes files from the build context of the manager image
+type DockerIgnore struct {
+	machinery.TemplateMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *DockerIgnore) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = ".dockerignore"
+	}
+
+	f.TemplateBody = dockerignoreTemplate
+
+	return nil
+}
+
+const dockerignoreTemplate = `# More info: https://docs.docker.com/engine/reference/builder/#dockerignore-file
+# Ignore build and test binaries, and the deployment manifests.
+bin/
+testbin/
+config/
+.git/
+`
@@ -27,7 +27,30 @@ type GoMod struct {
 	machinery.TemplateMixin
 	machinery.RepositoryMixin
 
+	// GoVersion is the minimum Go version of the project
+	GoVersion string
+
 	ControllerRuntimeVersion string
+
+	// HelmOperatorVersion is the version of the library providing the Helm reconciler
+	HelmOperatorVersion string
+
+	// HelmVersion is the version of the Helm library used by the Helm reconciler
+	HelmVersion string
+
+	// KubernetesVersion is the version of the Kubernetes client libraries
+	KubernetesVersion string
+
+	// LogrVersion is the version of the logging interface used by controller-runtime
+	LogrVersion string
+
+	// YAMLVersion is the version of the YAML library used to read the watches file
+	YAMLVersion string
+
+	// DockerDistributionVersion is the version which the Helm library replaces its docker/distribution
+	// dependency with. The replace directives of dependencies are not applied to the project, and the
+	// version required by the Helm library has dependencies which can no longer be downloaded.
+	DockerDistributionVersion string
 }
 
 // SetTemplateDefaults implements file.Template
@@ -46,9 +69,17 @@ func (f *GoMod) SetTemplateDefaults() error {
 const goModTemplate = `
 module {{ .Repo }}
 
-go 1.16
+go {{ .GoVersion }}
 
 require (
+	github.com/go-logr/logr {{ .LogrVersion }}
+	github.com/joelanford/helm-operator {{ .HelmOperatorVersion }}
+	helm.sh/helm/v3 {{ .HelmVersion }}
+	k8s.io/apimachinery {{ .KubernetesVersion }}
+	k8s.io/client-go {{ .KubernetesVersion }}
 	sigs.k8s.io/controller-runtime {{ .ControllerRuntimeVersion }}
+	sigs.k8s.io/yaml {{ .YAMLVersion }}
 )
+
+replace github.com/docker/distribution => github.com/docker/distribution {{ .DockerDistributionVersion }}
 `
@@ -0,0 +1,58 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdefault
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &RoleBindingNamespacePatch{}
+
+// RoleBindingNamespacePatch scaffolds a patch that sets the namespace of the role bindings of a watched
+// namespace, which the namespace of the kustomization replaces, and their service account subject, which
+// kustomize only updates for bindings in the namespace of the service account
+type RoleBindingNamespacePatch struct {
+	machinery.TemplateMixin
+	machinery.ProjectNameMixin
+
+	// Namespace is the watched namespace
+	Namespace string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *RoleBindingNamespacePatch) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "default", fmt.Sprintf("rolebinding_namespace_%s_patch.yaml", f.Namespace))
+	}
+
+	f.TemplateBody = roleBindingNamespacePatchTemplate
+
+	return nil
+}
+
+const roleBindingNamespacePatchTemplate = `- op: replace
+  path: /metadata/namespace
+  value: {{ .Namespace }}
+# The subject must match the namespace and the namePrefix of kustomization.yaml
+- op: replace
+  path: /subjects/0/name
+  value: {{ .ProjectName }}-controller-manager
+- op: replace
+  path: /subjects/0/namespace
+  value: {{ .ProjectName }}-system
+`
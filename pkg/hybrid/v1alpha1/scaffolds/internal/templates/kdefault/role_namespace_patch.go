@@ -0,0 +1,49 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdefault
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &RoleNamespacePatch{}
+
+// RoleNamespacePatch scaffolds a patch that sets the namespace of the manager role of a watched namespace,
+// which the namespace of the kustomization replaces
+type RoleNamespacePatch struct {
+	machinery.TemplateMixin
+
+	// Namespace is the watched namespace
+	Namespace string
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *RoleNamespacePatch) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "default", fmt.Sprintf("role_namespace_%s_patch.yaml", f.Namespace))
+	}
+
+	f.TemplateBody = roleNamespacePatchTemplate
+
+	return nil
+}
+
+const roleNamespacePatchTemplate = `- op: replace
+  path: /metadata/namespace
+  value: {{ .Namespace }}
+`
@@ -33,6 +33,9 @@ type Main struct {
 	machinery.DomainMixin
 	machinery.RepositoryMixin
 	machinery.ComponentConfigMixin
+
+	// LeaderElectionID is the default name of the lock used for leader election
+	LeaderElectionID string
 }
 
 // SetTemplateDefaults implements file.Template
@@ -185,18 +188,28 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"github.com/joelanford/helm-operator/pkg/annotation"
+	"github.com/joelanford/helm-operator/pkg/reconciler"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
+
+{{- if .ComponentConfig }}
+	configv1alpha1 "{{ .Repo }}/api/config/v1alpha1"
+{{- end }}
+	"{{ .Repo }}/internal/watches"
 	%s
 )
 
@@ -207,6 +220,9 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+{{- if .ComponentConfig }}
+	utilruntime.Must(configv1alpha1.AddToScheme(scheme))
+{{- end }}
 
 	%s
 }
@@ -228,6 +244,16 @@ func main() {
 		"Omit this flag to use the default configuration values. " +
 		"Command-line flags override configuration from this file.")
 {{- end }}
+	var leaderElectionID string
+	var leaderElectionNamespace string
+	flag.StringVar(&leaderElectionID, "leader-election-id", "{{ if not .ComponentConfig }}{{ .LeaderElectionID }}{{ end }}",
+		"Name of the configmap that is used for holding the leader lock.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "",
+		"Namespace in which to create the leader election configmap for holding the leader lock " +
+		"(required if running locally with leader election enabled).")
+	var watchesFile string
+	flag.StringVar(&watchesFile, "watches-file", "watches.yaml",
+		"The watches file listing the kinds reconciled by Helm reconcilers, and their charts.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -237,27 +263,55 @@ func main() {
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
 {{ if not .ComponentConfig }}
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
-		Scheme:                 scheme,
-		MetricsBindAddress:     metricsAddr,
-		Port:                   9443,
-		HealthProbeBindAddress: probeAddr,
-		LeaderElection:         enableLeaderElection,
-		LeaderElectionID:       "{{ hashFNV .Repo }}.{{ .Domain }}",
-	})
+	options := ctrl.Options{
+		Scheme:                  scheme,
+		MetricsBindAddress:      metricsAddr,
+		Port:                    9443,
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+	}
 {{- else }}
 	var err error
-	options := ctrl.Options{Scheme: scheme}
+	ctrlConfig := configv1alpha1.ProjectConfig{}
+	// Options that are already set take precedence over the config file
+	options := ctrl.Options{
+		Scheme:                  scheme,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: leaderElectionNamespace,
+	}
 	if configFile != "" {
-		options, err = options.AndFrom(ctrl.ConfigFile().AtPath(configFile))
+		options, err = options.AndFrom(ctrl.ConfigFile().AtPath(configFile).OfKind(&ctrlConfig))
 		if err != nil {
 			setupLog.Error(err, "unable to load the config file")
 			os.Exit(1)
 		}
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
+	// The --watches-file flag overrides the watches file of the config file
+	watchesFileSet := false
+	flag.Visit(func(f *flag.Flag) {
+		watchesFileSet = watchesFileSet || f.Name == "watches-file"
+	})
+	if ctrlConfig.WatchesFile != "" && !watchesFileSet {
+		watchesFile = ctrlConfig.WatchesFile
+	}
+	var reconcilerOptions []reconciler.Option
+	if ctrlConfig.MaxConcurrentReconciles != nil {
+		reconcilerOptions = append(reconcilerOptions,
+			reconciler.WithMaxConcurrentReconciles(*ctrlConfig.MaxConcurrentReconciles))
+	}
 {{- end }}
+
+	// Watch only the namespaces listed in WATCH_NAMESPACE, or all namespaces if it is empty
+	if namespaces := watchNamespaces(); len(namespaces) == 1 {
+		options.Namespace = namespaces[0]
+	} else if len(namespaces) > 1 {
+		options.NewCache = cache.MultiNamespacedCacheBuilder(namespaces)
+	}
+
+	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), options)
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
 		os.Exit(1)
@@ -265,6 +319,11 @@ func main() {
 
 	%s
 
+	if err = setupHelmReconcilers(mgr, watchesFile, {{ if .ComponentConfig }}ctrlConfig.HelmChartsDir, reconcilerOptions...{{ else }}""{{ end }}); err != nil {
+		setupLog.Error(err, "unable to set up helm reconcilers", "watchesFile", watchesFile)
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -280,4 +339,71 @@ func main() {
 		os.Exit(1)
 	}
 }
-`
+
+// watchNamespaces returns the namespaces listed in the WATCH_NAMESPACE
+// environment variable, a comma separated list.
+func watchNamespaces() []string {
+	var namespaces []string
+	for _, ns := range strings.Split(os.Getenv("WATCH_NAMESPACE"), ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces
+}
+
+// setupHelmReconcilers creates a Helm reconciler for every watch in
+// watchesFile, and registers them with mgr. Relative chart paths are
+// resolved against chartsDir, or the working directory if it is empty.
+// The options set by a watch take precedence over defaultOptions.
+func setupHelmReconcilers(mgr ctrl.Manager, watchesFile, chartsDir string, defaultOptions ...reconciler.Option) error {
+	ws, err := watches.Load(watchesFile, chartsDir)
+	if err != nil {
+		return fmt.Errorf("unable to load watches: %%w", err)
+	}
+
+	helmScheme := runtime.NewScheme()
+	for _, w := range ws {
+		options := append([]reconciler.Option{}, defaultOptions...)
+		options = append(options,
+			reconciler.WithChart(*w.Chart),
+			reconciler.WithGroupVersionKind(w.GroupVersionKind()),
+			reconciler.WithOverrideValues(w.OverrideValues),
+			reconciler.SkipDependentWatches(w.WatchDependentResources != nil && !*w.WatchDependentResources),
+			reconciler.WithInstallAnnotations(annotation.DefaultInstallAnnotations...),
+			reconciler.WithUpgradeAnnotations(annotation.DefaultUpgradeAnnotations...),
+			reconciler.WithUninstallAnnotations(annotation.DefaultUninstallAnnotations...),
+		)
+		if w.ReconcilePeriod != nil {
+			options = append(options, reconciler.WithReconcilePeriod(w.ReconcilePeriod.Duration))
+		}
+		if w.MaxConcurrentReconciles != nil {
+			options = append(options, reconciler.WithMaxConcurrentReconciles(*w.MaxConcurrentReconciles))
+		}
+
+		r, err := reconciler.New(options...)
+		if err != nil {
+			return fmt.Errorf("unable to create helm reconciler for %%s: %%w", w.GroupVersionKind(), err)
+		}
+		if err := r.SetupWithManager(helmManager{Manager: mgr, scheme: helmScheme}); err != nil {
+			return fmt.Errorf("unable to create controller for %%s: %%w", w.GroupVersionKind(), err)
+		}
+		setupLog.Info("configured watch", "gvk", w.GroupVersionKind(), "chartPath", w.ChartPath)
+	}
+	return nil
+}
+
+// helmManager is the manager of the Helm reconcilers, with a scheme of their own. They register the
+// kinds they reconcile as unstructured objects in the scheme of their manager, which would conflict
+// with the Go types registered in the scheme of the manager, used by the Go controllers to read the
+// chart-backed kinds.
+type helmManager struct {
+	ctrl.Manager
+	scheme *runtime.Scheme
+}
+
+// GetScheme returns the scheme of the Helm reconcilers
+func (m helmManager) GetScheme() *runtime.Scheme {
+	return m.scheme
+}
+`
\ No newline at end of file
@@ -0,0 +1,270 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/yaml"
+)
+
+// Discovery modes used to resolve the resources rendered by a chart.
+const (
+	// DiscoveryAuto uses the cluster configured in the kubeconfig if it can be
+	// reached, and the built-in resources otherwise.
+	DiscoveryAuto = "auto"
+	// DiscoveryCluster always uses the cluster configured in the kubeconfig.
+	DiscoveryCluster = "cluster"
+	// DiscoveryStatic only uses the built-in resources, no cluster access is needed.
+	DiscoveryStatic = "static"
+)
+
+// newRoleDiscovery returns the roleDiscoveryInterface for mode. The resource
+// mappings in resourcesFile, if set, take precedence over the discovered ones.
+func newRoleDiscovery(mode, resourcesFile string) (roleDiscoveryInterface, error) {
+	var dc roleDiscoveryInterface
+	switch mode {
+	case DiscoveryStatic:
+		dc = newStaticDiscovery()
+	case DiscoveryCluster:
+		clusterDC, err := newDiscoveryClient()
+		if err != nil {
+			return nil, err
+		}
+		dc = clusterDC
+	case DiscoveryAuto, "":
+		dc = newStaticDiscovery()
+		if clusterDC, err := newDiscoveryClient(); err != nil {
+			log.Infof("Using built-in resources for RBAC rules: %s", err)
+		} else if _, resources, err := clusterDC.ServerGroupsAndResources(); err != nil {
+			log.Infof("Using built-in resources for RBAC rules: failed to get server resources: %s", err)
+		} else {
+			dc = staticDiscovery{resources: resources}
+		}
+	default:
+		return nil, fmt.Errorf("unknown discovery mode %q", mode)
+	}
+
+	if resourcesFile == "" {
+		return dc, nil
+	}
+	extra, err := loadResourceMappings(resourcesFile)
+	if err != nil {
+		return nil, err
+	}
+	return extendedDiscovery{roleDiscoveryInterface: dc, extra: extra}, nil
+}
+
+// newDiscoveryClient returns a discovery client for the cluster configured
+// by the default kubeconfig loading rules.
+func newDiscoveryClient() (roleDiscoveryInterface, error) {
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Kubernetes config: %v", err)
+	}
+	return discovery.NewDiscoveryClientForConfig(cfg)
+}
+
+// staticDiscovery is a roleDiscoveryInterface that serves a fixed set of resources.
+type staticDiscovery struct {
+	resources []*metav1.APIResourceList
+}
+
+// ServerGroupsAndResources implements roleDiscoveryInterface
+func (d staticDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	return nil, d.resources, nil
+}
+
+// extendedDiscovery adds extra resources to the ones served by the embedded
+// roleDiscoveryInterface. Extra resources are listed first so they take
+// precedence when a kind is served by both.
+type extendedDiscovery struct {
+	roleDiscoveryInterface
+	extra []*metav1.APIResourceList
+}
+
+// ServerGroupsAndResources implements roleDiscoveryInterface
+func (d extendedDiscovery) ServerGroupsAndResources() ([]*metav1.APIGroup, []*metav1.APIResourceList, error) {
+	groups, resources, err := d.roleDiscoveryInterface.ServerGroupsAndResources()
+	if err != nil {
+		return nil, nil, err
+	}
+	return groups, append(append([]*metav1.APIResourceList{}, d.extra...), resources...), nil
+}
+
+// resourceMapping maps a kind to its resource name and scope. A list of
+// mappings can be supplied in a YAML file, e.g.:
+//
+//   - apiVersion: monitoring.coreos.com/v1
+//     kind: ServiceMonitor
+//     resource: servicemonitors
+//     namespaced: true
+type resourceMapping struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Resource   string `json:"resource"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// loadResourceMappings reads the resource mappings in path.
+func loadResourceMappings(path string) ([]*metav1.APIResourceList, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource mappings: %v", err)
+	}
+	mappings := []resourceMapping{}
+	if err := yaml.UnmarshalStrict(b, &mappings); err != nil {
+		return nil, fmt.Errorf("failed to parse resource mappings %q: %v", path, err)
+	}
+
+	resources := &resourceListBuilder{}
+	for i, m := range mappings {
+		if m.APIVersion == "" || m.Kind == "" || m.Resource == "" {
+			return nil, fmt.Errorf("invalid resource mapping %d in %q: apiVersion, kind and resource are required",
+				i, path)
+		}
+		resources.add(m.APIVersion, m.Kind, m.Resource, m.Namespaced)
+	}
+	return resources.lists, nil
+}
+
+// resourceListBuilder groups resources by group version.
+type resourceListBuilder struct {
+	lists []*metav1.APIResourceList
+}
+
+func (b *resourceListBuilder) add(groupVersion, kind, resource string, namespaced bool) {
+	var list *metav1.APIResourceList
+	for _, l := range b.lists {
+		if l.GroupVersion == groupVersion {
+			list = l
+			break
+		}
+	}
+	if list == nil {
+		list = &metav1.APIResourceList{GroupVersion: groupVersion}
+		b.lists = append(b.lists, list)
+	}
+	list.APIResources = append(list.APIResources, metav1.APIResource{
+		Name:       resource,
+		Kind:       kind,
+		Namespaced: namespaced,
+	})
+}
+
+// newStaticDiscovery returns a staticDiscovery that serves the Kubernetes
+// built-in kinds most commonly found in charts.
+func newStaticDiscovery() staticDiscovery {
+	b := &resourceListBuilder{}
+	for _, r := range builtinResources {
+		for _, gv := range r.groupVersions {
+			b.add(gv, r.kind, r.resource, r.namespaced)
+		}
+	}
+	return staticDiscovery{resources: b.lists}
+}
+
+// builtinResources are the Kubernetes built-in kinds known to newStaticDiscovery.
+var builtinResources = []struct {
+	groupVersions []string
+	kind          string
+	resource      string
+	namespaced    bool
+}{
+	// core
+	{[]string{"v1"}, "ConfigMap", "configmaps", true},
+	{[]string{"v1"}, "Endpoints", "endpoints", true},
+	{[]string{"v1"}, "Event", "events", true},
+	{[]string{"v1"}, "LimitRange", "limitranges", true},
+	{[]string{"v1"}, "Namespace", "namespaces", false},
+	{[]string{"v1"}, "PersistentVolume", "persistentvolumes", false},
+	{[]string{"v1"}, "PersistentVolumeClaim", "persistentvolumeclaims", true},
+	{[]string{"v1"}, "Pod", "pods", true},
+	{[]string{"v1"}, "PodTemplate", "podtemplates", true},
+	{[]string{"v1"}, "ReplicationController", "replicationcontrollers", true},
+	{[]string{"v1"}, "ResourceQuota", "resourcequotas", true},
+	{[]string{"v1"}, "Secret", "secrets", true},
+	{[]string{"v1"}, "Service", "services", true},
+	{[]string{"v1"}, "ServiceAccount", "serviceaccounts", true},
+
+	// admissionregistration.k8s.io
+	{[]string{"admissionregistration.k8s.io/v1", "admissionregistration.k8s.io/v1beta1"},
+		"MutatingWebhookConfiguration", "mutatingwebhookconfigurations", false},
+	{[]string{"admissionregistration.k8s.io/v1", "admissionregistration.k8s.io/v1beta1"},
+		"ValidatingWebhookConfiguration", "validatingwebhookconfigurations", false},
+
+	// apiextensions.k8s.io
+	{[]string{"apiextensions.k8s.io/v1", "apiextensions.k8s.io/v1beta1"},
+		"CustomResourceDefinition", "customresourcedefinitions", false},
+
+	// apiregistration.k8s.io
+	{[]string{"apiregistration.k8s.io/v1", "apiregistration.k8s.io/v1beta1"},
+		"APIService", "apiservices", false},
+
+	// apps
+	{[]string{"apps/v1"}, "ControllerRevision", "controllerrevisions", true},
+	{[]string{"apps/v1"}, "DaemonSet", "daemonsets", true},
+	{[]string{"apps/v1"}, "Deployment", "deployments", true},
+	{[]string{"apps/v1"}, "ReplicaSet", "replicasets", true},
+	{[]string{"apps/v1"}, "StatefulSet", "statefulsets", true},
+
+	// autoscaling
+	{[]string{"autoscaling/v1", "autoscaling/v2beta1", "autoscaling/v2beta2"},
+		"HorizontalPodAutoscaler", "horizontalpodautoscalers", true},
+
+	// batch
+	{[]string{"batch/v1"}, "Job", "jobs", true},
+	{[]string{"batch/v1", "batch/v1beta1"}, "CronJob", "cronjobs", true},
+
+	// coordination.k8s.io
+	{[]string{"coordination.k8s.io/v1"}, "Lease", "leases", true},
+
+	// discovery.k8s.io
+	{[]string{"discovery.k8s.io/v1", "discovery.k8s.io/v1beta1"}, "EndpointSlice", "endpointslices", true},
+
+	// networking.k8s.io, and the deprecated extensions group
+	{[]string{"networking.k8s.io/v1", "networking.k8s.io/v1beta1", "extensions/v1beta1"},
+		"Ingress", "ingresses", true},
+	{[]string{"networking.k8s.io/v1", "networking.k8s.io/v1beta1"}, "IngressClass", "ingressclasses", false},
+	{[]string{"networking.k8s.io/v1"}, "NetworkPolicy", "networkpolicies", true},
+
+	// policy
+	{[]string{"policy/v1", "policy/v1beta1"}, "PodDisruptionBudget", "poddisruptionbudgets", true},
+	{[]string{"policy/v1beta1"}, "PodSecurityPolicy", "podsecuritypolicies", false},
+
+	// rbac.authorization.k8s.io
+	{[]string{"rbac.authorization.k8s.io/v1", "rbac.authorization.k8s.io/v1beta1"},
+		"ClusterRole", "clusterroles", false},
+	{[]string{"rbac.authorization.k8s.io/v1", "rbac.authorization.k8s.io/v1beta1"},
+		"ClusterRoleBinding", "clusterrolebindings", false},
+	{[]string{"rbac.authorization.k8s.io/v1", "rbac.authorization.k8s.io/v1beta1"},
+		"Role", "roles", true},
+	{[]string{"rbac.authorization.k8s.io/v1", "rbac.authorization.k8s.io/v1beta1"},
+		"RoleBinding", "rolebindings", true},
+
+	// scheduling.k8s.io
+	{[]string{"scheduling.k8s.io/v1"}, "PriorityClass", "priorityclasses", false},
+
+	// storage.k8s.io
+	{[]string{"storage.k8s.io/v1"}, "CSIDriver", "csidrivers", false},
+	{[]string{"storage.k8s.io/v1"}, "StorageClass", "storageclasses", false},
+	{[]string{"storage.k8s.io/v1"}, "VolumeAttachment", "volumeattachments", false},
+}
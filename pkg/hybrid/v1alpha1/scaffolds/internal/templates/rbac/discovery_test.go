@@ -0,0 +1,239 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withoutCluster points the kubeconfig to a missing file, so that no cluster can be reached.
+func withoutCluster(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for env, value := range map[string]string{
+		"KUBECONFIG":              filepath.Join(dir, "missing"),
+		"KUBERNETES_SERVICE_HOST": "",
+	} {
+		old, set := os.LookupEnv(env)
+		if err := os.Setenv(env, value); err != nil {
+			t.Fatal(err)
+		}
+		env := env
+		t.Cleanup(func() {
+			if set {
+				os.Setenv(env, old)
+			} else {
+				os.Unsetenv(env)
+			}
+		})
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+}
+
+// writeResourcesFile writes content to a resources file in a temporary directory and returns its path.
+func writeResourcesFile(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "discovery-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "resources.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const serviceMonitorMapping = `- apiVersion: monitoring.coreos.com/v1
+  kind: ServiceMonitor
+  resource: servicemonitors
+  namespaced: true
+`
+
+func TestNewRoleDiscovery(t *testing.T) {
+	withoutCluster(t)
+
+	type resolved struct {
+		resource   string
+		namespaced bool
+		ok         bool
+	}
+	tests := []struct {
+		name          string
+		mode          string
+		resourcesFile string
+		groupVersion  string
+		kind          string
+		want          resolved
+		wantErr       string
+	}{
+		{
+			name:         "static core kind",
+			mode:         DiscoveryStatic,
+			groupVersion: "v1",
+			kind:         "ConfigMap",
+			want:         resolved{"configmaps", true, true},
+		},
+		{
+			name:         "static cluster-scoped kind",
+			mode:         DiscoveryStatic,
+			groupVersion: "rbac.authorization.k8s.io/v1",
+			kind:         "ClusterRoleBinding",
+			want:         resolved{"clusterrolebindings", false, true},
+		},
+		{
+			name:         "static kind of a deprecated version",
+			mode:         DiscoveryStatic,
+			groupVersion: "extensions/v1beta1",
+			kind:         "Ingress",
+			want:         resolved{"ingresses", true, true},
+		},
+		{
+			name:         "static kind of an unknown version",
+			mode:         DiscoveryStatic,
+			groupVersion: "apps/v1beta2",
+			kind:         "Deployment",
+		},
+		{
+			name:         "unknown kind falls through the static table",
+			mode:         DiscoveryStatic,
+			groupVersion: "monitoring.coreos.com/v1",
+			kind:         "ServiceMonitor",
+		},
+		{
+			name:         "auto falls back to static without a cluster",
+			mode:         DiscoveryAuto,
+			groupVersion: "apps/v1",
+			kind:         "Deployment",
+			want:         resolved{"deployments", true, true},
+		},
+		{
+			name:         "empty mode is auto",
+			mode:         "",
+			groupVersion: "v1",
+			kind:         "Namespace",
+			want:         resolved{"namespaces", false, true},
+		},
+		{
+			name:          "resources file mapping",
+			mode:          DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t, serviceMonitorMapping),
+			groupVersion:  "monitoring.coreos.com/v1",
+			kind:          "ServiceMonitor",
+			want:          resolved{"servicemonitors", true, true},
+		},
+		{
+			name: "resources file mapping takes precedence",
+			mode: DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t,
+				"- apiVersion: v1\n  kind: ConfigMap\n  resource: configmaps\n  namespaced: false\n"),
+			groupVersion: "v1",
+			kind:         "ConfigMap",
+			want:         resolved{"configmaps", false, true},
+		},
+		{
+			name:          "resources file keeps the static table",
+			mode:          DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t, serviceMonitorMapping),
+			groupVersion:  "v1",
+			kind:          "Secret",
+			want:          resolved{"secrets", true, true},
+		},
+		{
+			name:    "cluster without a cluster",
+			mode:    DiscoveryCluster,
+			wantErr: "failed to get Kubernetes config",
+		},
+		{
+			name:    "unknown mode",
+			mode:    "dynamic",
+			wantErr: `unknown discovery mode "dynamic"`,
+		},
+		{
+			name:          "missing resources file",
+			mode:          DiscoveryStatic,
+			resourcesFile: filepath.Join("testdata", "missing.yaml"),
+			wantErr:       "failed to read resource mappings",
+		},
+		{
+			name:          "resources file with an unknown field",
+			mode:          DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t, serviceMonitorMapping+"  scope: Namespaced\n"),
+			wantErr:       `unknown field "scope"`,
+		},
+		{
+			name:          "resources file that is not a list",
+			mode:          DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t, "apiVersion: v1\nkind: ConfigMap\n"),
+			wantErr:       "failed to parse resource mappings",
+		},
+		{
+			name:          "resources file mapping without resource",
+			mode:          DiscoveryStatic,
+			resourcesFile: writeResourcesFile(t, serviceMonitorMapping+"- apiVersion: v1\n  kind: Foo\n"),
+			wantErr:       "invalid resource mapping 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc, err := newRoleDiscovery(tt.mode, tt.resourcesFile)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			_, resources, err := dc.ServerGroupsAndResources()
+			checkErr(t, err, "")
+			var got resolved
+			got.resource, got.namespaced, got.ok = getResource(resources, tt.groupVersion, tt.kind)
+			if got != tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNewStaticDiscovery(t *testing.T) {
+	_, resources, err := newStaticDiscovery().ServerGroupsAndResources()
+	checkErr(t, err, "")
+
+	// Each kind is listed once per group version, with the version of its group version list
+	seen := map[string]bool{}
+	for _, list := range resources {
+		if list.GroupVersion == "" {
+			t.Errorf("expected a group version, got an empty one")
+		}
+		for _, r := range list.APIResources {
+			key := list.GroupVersion + "/" + r.Kind
+			if seen[key] {
+				t.Errorf("duplicate resource %s", key)
+			}
+			seen[key] = true
+		}
+	}
+	for _, r := range builtinResources {
+		for _, gv := range r.groupVersions {
+			name, namespaced, ok := getResource(resources, gv, r.kind)
+			if !ok || name != r.resource || namespaced != r.namespaced {
+				t.Errorf("expected %s %s to be served as %s (namespaced %v), got %s (namespaced %v, found %v)",
+					gv, r.kind, r.resource, r.namespaced, name, namespaced, ok)
+			}
+		}
+	}
+}
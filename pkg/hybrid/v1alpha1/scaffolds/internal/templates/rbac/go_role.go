@@ -0,0 +1,52 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &GoRole{}
+
+// GoRole scaffolds the role.yaml file generated by controller-gen from the RBAC markers of the Go
+// controllers. It is kept apart from the role of the manager, which holds the rules of the charts,
+// so that "make manifests" does not overwrite them. Until the controllers have RBAC markers,
+// controller-gen writes no role, so the role is scaffolded without rules.
+type GoRole struct {
+	machinery.TemplateMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *GoRole) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "rbac", "go", "role.yaml")
+	}
+
+	f.TemplateBody = goRoleTemplate
+
+	return nil
+}
+
+const goRoleTemplate = `
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  creationTimestamp: null
+  name: manager-role-go
+rules: []
+`
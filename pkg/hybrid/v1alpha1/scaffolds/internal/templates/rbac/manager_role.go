@@ -29,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
 	"sigs.k8s.io/yaml"
 )
 
@@ -39,6 +40,19 @@ var defaultRoleFile = filepath.Join("config", "rbac", "role.yaml")
 // ManagerRole scaffolds the role.yaml file
 type ManagerRole struct {
 	machinery.TemplateMixin
+
+	// WatchNamespaces are the namespaces watched by the manager. If set, role.yaml has a role in each of
+	// them instead of a cluster role, and the rules of cluster-scoped resources are left to cluster_role.yaml.
+	WatchNamespaces []string
+}
+
+// Namespaces returns the namespaces of the roles of role.yaml, an empty namespace stands for the cluster
+// role of a manager watching all namespaces
+func (f *ManagerRole) Namespaces() []string {
+	if len(f.WatchNamespaces) == 0 {
+		return []string{""}
+	}
+	return f.WatchNamespaces
 }
 
 // SetTemplateDefaults implements machinery.Template
@@ -56,14 +70,23 @@ const (
 	rulesMarker = "rules"
 )
 
-const roleTemplate = `apiVersion: rbac.authorization.k8s.io/v1
+const roleTemplate = `{{ range $i, $ns := .Namespaces }}{{ if $i }}---
+{{ end }}apiVersion: rbac.authorization.k8s.io/v1
+{{- if $ns }}
+kind: Role
+metadata:
+  name: manager-role-{{ $ns }}
+  namespace: {{ $ns }}
+{{- else }}
 kind: ClusterRole
 metadata:
   name: manager-role
+{{- end }}
 rules:
 ##
 ## Base operator rules
 ##
+{{- if not $ns }}
 # We need to get namespaces so the operator can read namespaces to ensure they exist
 - apiGroups:
   - ""
@@ -71,6 +94,7 @@ rules:
   - namespaces
   verbs:
   - get
+{{- end }}
 # We need to manage Helm release secrets
 - apiGroups:
   - ""
@@ -87,6 +111,68 @@ rules:
   - create
 
 %s
+{{ end }}`
+
+// ChartRules returns the cluster and namespaced rules needed to manage the default manifests
+// of chart. Their resources are resolved as configured by discovery, one of DiscoveryAuto,
+// DiscoveryCluster or DiscoveryStatic, and resourcesFile, an optional file with additional
+// resource mappings.
+func ChartRules(chart *chart.Chart, discovery, resourcesFile string) (clusterRules,
+	namespacedRules []rbacv1.PolicyRule, err error) {
+	dc, err := newRoleDiscovery(discovery, resourcesFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to set up resource discovery: %v", err)
+	}
+
+	clusterRules, namespacedRules, err = generateRoleRules(dc, chart)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate RBAC rules: %v", err)
+	}
+
+	return clusterRules, namespacedRules, nil
+}
+
+// rulesFragmentData is the data passed to rulesFragment.
+type rulesFragmentData struct {
+	Resource          *resource.Resource
+	Rules             []rbacv1.PolicyRule
+	OmitResourceRules bool
+}
+
+const rulesFragment = `##
+## Rules for {{ .Resource.QualifiedGroup }}/{{ .Resource.Version }}, Kind: {{ .Resource.Kind }}
+##
+{{- if not .OmitResourceRules }}
+- apiGroups:
+  - {{ .Resource.QualifiedGroup }}
+  resources:
+  - {{ .Resource.Plural }}
+  - {{ .Resource.Plural }}/status
+  - {{ .Resource.Plural }}/finalizers
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+{{- end }}
+{{- range .Rules }}
+- apiGroups:
+  {{- range .APIGroups }}
+  - {{ printf "%q" . }}
+  {{- end }}
+  resources:
+  {{- range .Resources }}
+  - {{ . }}
+  {{- end }}
+  verbs:
+  {{- range .Verbs }}
+  - {{ printf "%q" . }}
+  {{- end }}
+{{- end }}
+
 `
 
 // roleDiscoveryInterface is an interface that contains just the discovery
@@ -177,6 +263,12 @@ func generateRoleRules(dc roleDiscoveryInterface, chart *chart.Chart) ([]rbacv1.
 	return clusterRules, namespacedRules, nil
 }
 
+// RenderChart renders the templates of c with its default values, as done to generate its RBAC rules
+func RenderChart(c *chart.Chart) error {
+	_, err := getDefaultManifests(c)
+	return err
+}
+
 func getDefaultManifests(c *chart.Chart) ([]releaseutil.Manifest, error) {
 	install := action.NewInstall(&action.Configuration{})
 	install.DryRun = true
@@ -219,5 +311,8 @@ func buildRulesFromGroups(groups map[string]map[string]struct{}) []rbacv1.Policy
 			Verbs:     []string{rbacv1.VerbAll},
 		})
 	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].APIGroups[0] < rules[j].APIGroups[0]
+	})
 	return rules
 }
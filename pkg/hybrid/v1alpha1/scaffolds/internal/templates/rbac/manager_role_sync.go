@@ -0,0 +1,360 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// ChartKind is a chart-backed kind, and the rules needed to manage the default manifests of its chart
+type ChartKind struct {
+	Resource *resource.Resource
+	Rules    []rbacv1.PolicyRule
+
+	// OmitResourceRules leaves the rules for the custom resources of the kind out of its section, when
+	// they are granted by another role. A kind without rules then has no section.
+	OmitResourceRules bool
+}
+
+// Permission is an API resource granted or needed by the rules of a kind
+type Permission struct {
+	// Kind is the kind whose chart needs the permission, or whose rules grant it
+	Kind string `json:"kind"`
+	// APIGroup and Resource are the API resource of the permission
+	APIGroup string `json:"apiGroup"`
+	Resource string `json:"resource"`
+}
+
+// roleSeparator separates the roles of role.yaml, which has a role in each watched namespace if the
+// manager only watches some namespaces. The functions below apply to each of them.
+const roleSeparator = "\n---\n"
+
+// RoleRules returns the rules of each role in content, the content of role.yaml or cluster_role.yaml
+func RoleRules(content []byte) ([][]rbacv1.PolicyRule, error) {
+	var rules [][]rbacv1.PolicyRule
+	for _, doc := range strings.Split(string(content), roleSeparator) {
+		// The rules of Role and ClusterRole objects are the same
+		role := rbacv1.ClusterRole{}
+		if err := yaml.Unmarshal([]byte(doc), &role); err != nil {
+			return nil, err
+		}
+		rules = append(rules, role.Rules)
+	}
+	return rules, nil
+}
+
+// roleSection is the section of role.yaml added by create api for a kind, see UpsertRole
+type roleSection struct {
+	gvk schema.GroupVersionKind
+	// start and end are the indexes of the first line of the section, and of the line following it
+	start, end int
+}
+
+// sectionHeaderRegexp matches the second line of the header of the section of a kind, see rulesFragment
+var sectionHeaderRegexp = regexp.MustCompile(`^## Rules for (.*)/([^/]+), Kind: (.+)$`)
+
+// parseRoleSections returns the sections of the kinds in the lines of role.yaml. A section ends
+// with the empty line following its rules, so that the rules added by users after it are kept,
+// or at the next section or the scaffold marker if that line was removed.
+func parseRoleSections(lines []string) []roleSection {
+	marker := machinery.NewMarkerFor(defaultRoleFile, rulesMarker).String()
+
+	var sections []roleSection
+	for i, line := range lines {
+		if len(sections) != 0 && sections[len(sections)-1].end == len(lines) {
+			switch {
+			case strings.TrimSpace(line) == marker || isSectionStart(lines, i):
+				sections[len(sections)-1].end = i
+			case strings.TrimSpace(line) == "":
+				sections[len(sections)-1].end = i + 1
+			}
+		}
+		if isSectionStart(lines, i) {
+			m := sectionHeaderRegexp.FindStringSubmatch(lines[i+1])
+			sections = append(sections, roleSection{
+				gvk:   schema.GroupVersionKind{Group: m[1], Version: m[2], Kind: m[3]},
+				start: i,
+				end:   len(lines),
+			})
+		}
+	}
+	return sections
+}
+
+func isSectionStart(lines []string, i int) bool {
+	return lines[i] == "##" && i+1 < len(lines) && sectionHeaderRegexp.MatchString(lines[i+1])
+}
+
+// sectionRules returns the rules of a section
+func sectionRules(lines []string, s roleSection) ([]rbacv1.PolicyRule, error) {
+	rules := []rbacv1.PolicyRule{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[s.start:s.end], "\n")), &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules for %s: %v", s.gvk, err)
+	}
+	return rules, nil
+}
+
+// renderSection returns the section of k, as added by UpsertRole, or an empty string if k has no rules
+func renderSection(k ChartKind) (string, error) {
+	if k.OmitResourceRules && len(k.Rules) == 0 {
+		return "", nil
+	}
+	buf := &bytes.Buffer{}
+	tmpl := template.Must(template.New("rules").Parse(rulesFragment))
+	data := rulesFragmentData{Resource: k.Resource, Rules: k.Rules, OmitResourceRules: k.OmitResourceRules}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func chartKindGVK(k ChartKind) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: k.Resource.QualifiedGroup(), Version: k.Resource.Version, Kind: k.Resource.Kind}
+}
+
+// DiffRole compares the rules of role.yaml, in content, with the rules needed by the chart-backed kinds
+// of a project, whose other kinds are otherKinds. Missing permissions are needed by a chart-backed kind
+// and granted by no rule. Excess permissions are granted by the section of a chart-backed kind and needed
+// by none, or by the section of a kind that is not in the project.
+func DiffRole(content []byte, kinds []ChartKind, otherKinds []schema.GroupVersionKind) (missing,
+	excess []Permission, err error) {
+	seenMissing, seenExcess := map[Permission]bool{}, map[Permission]bool{}
+	for _, doc := range strings.Split(string(content), roleSeparator) {
+		docMissing, docExcess, err := diffRole([]byte(doc), kinds, otherKinds)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The permissions are only reported once, if several roles miss or grant them
+		missing = appendNew(missing, seenMissing, docMissing)
+		excess = appendNew(excess, seenExcess, docExcess)
+	}
+	return missing, excess, nil
+}
+
+// appendNew appends the permissions of add that are not in seen to perms, and adds them to seen
+func appendNew(perms []Permission, seen map[Permission]bool, add []Permission) []Permission {
+	for _, p := range add {
+		if !seen[p] {
+			seen[p] = true
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+// diffRole is DiffRole for a single role
+func diffRole(content []byte, kinds []ChartKind, otherKinds []schema.GroupVersionKind) (missing,
+	excess []Permission, err error) {
+	role := rbacv1.ClusterRole{}
+	if err := yaml.Unmarshal(content, &role); err != nil {
+		return nil, nil, err
+	}
+
+	// The rules of the kinds include the ones for their custom resources
+	needed := map[schema.GroupResource]bool{}
+	chartKinds := map[schema.GroupVersionKind]bool{}
+	for _, k := range kinds {
+		section, err := renderSection(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules := []rbacv1.PolicyRule{}
+		if err := yaml.Unmarshal([]byte(section), &rules); err != nil {
+			return nil, nil, err
+		}
+		gvk := chartKindGVK(k)
+		chartKinds[gvk] = true
+		for _, gr := range groupResources(rules) {
+			needed[gr] = true
+			if !Covers(role.Rules, gr.Group, gr.Resource) {
+				missing = append(missing, Permission{Kind: gvk.String(), APIGroup: gr.Group, Resource: gr.Resource})
+			}
+		}
+	}
+
+	projectKinds := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range otherKinds {
+		projectKinds[gvk] = true
+	}
+	lines := strings.Split(string(content), "\n")
+	for _, s := range parseRoleSections(lines) {
+		// The sections of the other kinds of the project are not generated from a chart
+		if !chartKinds[s.gvk] && projectKinds[s.gvk] {
+			continue
+		}
+		rules, err := sectionRules(lines, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, gr := range groupResources(rules) {
+			if !needed[gr] || !chartKinds[s.gvk] {
+				excess = append(excess, Permission{Kind: s.gvk.String(), APIGroup: gr.Group, Resource: gr.Resource})
+			}
+		}
+	}
+
+	return missing, excess, nil
+}
+
+// SyncRole returns role.yaml, in content, with the sections of the chart-backed kinds of a project
+// generated again, and the sections of kinds that are neither in kinds nor in otherKinds removed.
+// Sections are added before the scaffold marker for the kinds that have none.
+func SyncRole(content []byte, kinds []ChartKind, otherKinds []schema.GroupVersionKind) ([]byte, error) {
+	docs := strings.Split(string(content), roleSeparator)
+	for i, doc := range docs {
+		synced, err := syncRole(doc, kinds, otherKinds)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = synced
+	}
+	return []byte(strings.Join(docs, roleSeparator)), nil
+}
+
+// syncRole is SyncRole for a single role
+func syncRole(content string, kinds []ChartKind, otherKinds []schema.GroupVersionKind) (string, error) {
+	sections := map[schema.GroupVersionKind]string{}
+	var added []schema.GroupVersionKind
+	for _, k := range kinds {
+		section, err := renderSection(k)
+		if err != nil {
+			return "", err
+		}
+		gvk := chartKindGVK(k)
+		sections[gvk] = section
+		added = append(added, gvk)
+	}
+	projectKinds := map[schema.GroupVersionKind]bool{}
+	for _, gvk := range otherKinds {
+		projectKinds[gvk] = true
+	}
+
+	lines := strings.Split(content, "\n")
+	buf := &bytes.Buffer{}
+	next := 0
+	for _, s := range parseRoleSections(lines) {
+		buf.WriteString(strings.Join(lines[next:s.start], "\n"))
+		if next < s.start {
+			buf.WriteString("\n")
+		}
+		next = s.end
+
+		section, ok := sections[s.gvk]
+		switch {
+		case ok:
+			buf.WriteString(section)
+			delete(sections, s.gvk)
+		case projectKinds[s.gvk]:
+			buf.WriteString(strings.Join(lines[s.start:s.end], "\n"))
+			buf.WriteString("\n")
+		}
+	}
+
+	// The kinds without a section are added before the marker, or at the end of the file
+	marker := machinery.NewMarkerFor(defaultRoleFile, rulesMarker).String()
+	rest := lines[next:]
+	markerIndex := len(rest)
+	for i, line := range rest {
+		if strings.TrimSpace(line) == marker {
+			markerIndex = i
+			break
+		}
+	}
+	buf.WriteString(strings.Join(rest[:markerIndex], "\n"))
+	if markerIndex != 0 && markerIndex < len(rest) {
+		buf.WriteString("\n")
+	}
+	for _, gvk := range added {
+		if section, ok := sections[gvk]; ok {
+			buf.WriteString(section)
+		}
+	}
+	buf.WriteString(strings.Join(rest[markerIndex:], "\n"))
+
+	return buf.String(), nil
+}
+
+// UpsertRole returns role.yaml, in content, with the section of the kind k generated again if it has
+// one, or added before the scaffold marker otherwise. The section is removed if k has no rules. The
+// sections of the other kinds are kept. It also applies to cluster_role.yaml, see ClusterRole.
+func UpsertRole(content []byte, k ChartKind) ([]byte, error) {
+	gvk := chartKindGVK(k)
+	var otherKinds []schema.GroupVersionKind
+	for _, s := range parseRoleSections(strings.Split(string(content), "\n")) {
+		if s.gvk != gvk {
+			otherKinds = append(otherKinds, s.gvk)
+		}
+	}
+	return SyncRole(content, []ChartKind{k}, otherKinds)
+}
+
+// groupResources returns the API resources granted by rules, in order
+func groupResources(rules []rbacv1.PolicyRule) []schema.GroupResource {
+	var grs []schema.GroupResource
+	seen := map[schema.GroupResource]bool{}
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, res := range rule.Resources {
+				gr := schema.GroupResource{Group: group, Resource: res}
+				if !seen[gr] {
+					seen[gr] = true
+					grs = append(grs, gr)
+				}
+			}
+		}
+	}
+	return grs
+}
+
+// Covers returns true if role grants the verbs used by the Helm reconciler on resource of group
+func Covers(role []rbacv1.PolicyRule, group, resource string) bool {
+	verbs := map[string]bool{}
+	for _, rule := range role {
+		if !containsOrAll(rule.APIGroups, group) || !containsOrAll(rule.Resources, resource) {
+			continue
+		}
+		for _, verb := range rule.Verbs {
+			verbs[verb] = true
+		}
+	}
+	if verbs[rbacv1.VerbAll] {
+		return true
+	}
+	for _, verb := range []string{"create", "delete", "get", "list", "patch", "update", "watch"} {
+		if !verbs[verb] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsOrAll(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,454 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	testRoleHead = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: manager-role
+rules:
+##
+## Base operator rules
+##
+- apiGroups:
+  - ""
+  resources:
+  - secrets
+  verbs:
+  - "*"
+
+`
+	testClusterRoleHead = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: manager-cluster-role
+rules:
+##
+## Base operator rules
+##
+- apiGroups:
+  - ""
+  resources:
+  - namespaces
+  verbs:
+  - get
+
+`
+	testRulesMarker = "#+kubebuilder:scaffold:rules\n"
+)
+
+// testRule returns a rule granting verb on the core resource, as added by users.
+func testRule(resource, verb string) string {
+	return "# Added by hand\n- apiGroups:\n  - \"\"\n  resources:\n  - " + resource + "\n  verbs:\n  - " + verb + "\n"
+}
+
+// testSectionHeader returns the header of the section of the cache.example.com/v1alpha1 kind.
+func testSectionHeader(kind string) string {
+	return "##\n## Rules for cache.example.com/v1alpha1, Kind: " + kind + "\n##\n"
+}
+
+// testSectionRules returns the rules of the section of a kind, granting all verbs on the core resources.
+// The rules for the custom resources of the kind are omitted if plural is empty.
+func testSectionRules(plural string, resources ...string) string {
+	var b strings.Builder
+	if plural != "" {
+		b.WriteString("- apiGroups:\n  - cache.example.com\n  resources:\n  - " + plural + "\n  - " + plural +
+			"/status\n  - " + plural + "/finalizers\n  verbs:\n  - create\n  - delete\n  - get\n  - list\n" +
+			"  - patch\n  - update\n  - watch\n")
+	}
+	for _, res := range resources {
+		b.WriteString("- apiGroups:\n  - \"\"\n  resources:\n  - " + res + "\n  verbs:\n  - \"*\"\n")
+	}
+	return b.String()
+}
+
+// testSection returns the section of a kind, as rendered by rulesFragment.
+func testSection(kind, plural string, resources ...string) string {
+	return testSectionHeader(kind) + testSectionRules(plural, resources...) + "\n"
+}
+
+// testChartKind returns the chart-backed kind whose chart needs all verbs on the core resources.
+func testChartKind(kind, plural string, resources ...string) ChartKind {
+	k := ChartKind{Resource: newTestResource(kind, plural)}
+	for _, res := range resources {
+		k.Rules = append(k.Rules, rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{res},
+			Verbs: []string{"*"}})
+	}
+	return k
+}
+
+// testClusterChartKind returns testChartKind for cluster_role.yaml in namespaced mode, without the
+// rules for the custom resources of the kind.
+func testClusterChartKind(kind, plural string, resources ...string) ChartKind {
+	k := testChartKind(kind, plural, resources...)
+	k.OmitResourceRules = true
+	return k
+}
+
+var testOtherGVK = schema.GroupVersionKind{Group: "cache.example.com", Version: "v1alpha1", Kind: "Other"}
+
+func TestSyncRole(t *testing.T) {
+	memcached := testChartKind("Memcached", "memcacheds", "services")
+	tests := []struct {
+		name       string
+		content    string
+		kinds      []ChartKind
+		otherKinds []schema.GroupVersionKind
+		want       string
+	}{
+		{
+			name:    "up to date",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+			kinds:   []ChartKind{memcached},
+			want:    testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name:    "rules of a kind updated",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "configmaps") + testRulesMarker,
+			kinds:   []ChartKind{testChartKind("Memcached", "memcacheds", "services", "secrets")},
+			want:    testRoleHead + testSection("Memcached", "memcacheds", "services", "secrets") + testRulesMarker,
+		},
+		{
+			name:    "section added before the marker",
+			content: testRoleHead + testRulesMarker,
+			kinds:   []ChartKind{memcached},
+			want:    testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name:    "section added at the end without a marker",
+			content: testRoleHead,
+			kinds:   []ChartKind{memcached},
+			want:    testRoleHead + testSection("Memcached", "memcacheds", "services"),
+		},
+		{
+			name: "section of a kind not in the project removed",
+			content: testRoleHead + testSection("Redis", "redis", "services") +
+				testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			want:  testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name:       "section of another kind of the project kept",
+			content:    testRoleHead + testSection("Other", "others", "configmaps") + testRulesMarker,
+			kinds:      []ChartKind{memcached},
+			otherKinds: []schema.GroupVersionKind{testOtherGVK},
+			want: testRoleHead + testSection("Other", "others", "configmaps") +
+				testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name: "user rules between sections kept",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "configmaps") +
+				testRule("pods", "get") + "\n" + testSection("Redis", "redis", "services") +
+				testRule("nodes", "list") + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			want: testRoleHead + testSection("Memcached", "memcacheds", "services") +
+				testRule("pods", "get") + "\n" + testRule("nodes", "list") + testRulesMarker,
+		},
+		{
+			name: "section without its empty line ended by the marker",
+			content: testRoleHead + testSectionHeader("Memcached") + testSectionRules("memcacheds", "configmaps") +
+				testRulesMarker,
+			kinds: []ChartKind{memcached},
+			want:  testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name: "rules of a section without its header kept",
+			content: testRoleHead + testSectionRules("memcacheds", "configmaps") + "\n" +
+				testRulesMarker,
+			kinds: []ChartKind{memcached},
+			want: testRoleHead + testSectionRules("memcacheds", "configmaps") + "\n" +
+				testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name: "role in each watched namespace",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "configmaps") + testRulesMarker +
+				"---\n" + testRoleHead + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			want: testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker +
+				"---\n" + testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+		},
+		{
+			name:    "cluster role of a namespaced project",
+			content: testClusterRoleHead + testSection("Memcached", "", "nodes") + testRulesMarker,
+			kinds:   []ChartKind{testClusterChartKind("Memcached", "memcacheds", "nodes", "namespaces")},
+			want:    testClusterRoleHead + testSection("Memcached", "", "nodes", "namespaces") + testRulesMarker,
+		},
+		{
+			name:    "cluster role of a namespaced project without cluster rules",
+			content: testClusterRoleHead + testSection("Memcached", "", "nodes") + testRulesMarker,
+			kinds: []ChartKind{testClusterChartKind("Memcached", "memcacheds"),
+				testClusterChartKind("Redis", "redis")},
+			want: testClusterRoleHead + testRulesMarker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := SyncRole([]byte(tt.content), tt.kinds, tt.otherKinds)
+			checkErr(t, err, "")
+			if string(content) != tt.want {
+				t.Errorf("expected role:\n%s\ngot:\n%s", tt.want, content)
+			}
+		})
+	}
+}
+
+func TestUpsertRole(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		kind    ChartKind
+		want    string
+	}{
+		{
+			name:    "section added, the other ones kept",
+			content: testRoleHead + testSection("Redis", "redis", "services") + testRulesMarker,
+			kind:    testChartKind("Memcached", "memcacheds", "configmaps"),
+			want: testRoleHead + testSection("Redis", "redis", "services") +
+				testSection("Memcached", "memcacheds", "configmaps") + testRulesMarker,
+		},
+		{
+			name: "section replaced, user rules kept",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") + testRule("pods", "get") +
+				testSection("Redis", "redis", "services") + testRulesMarker,
+			kind: testChartKind("Memcached", "memcacheds", "configmaps"),
+			want: testRoleHead + testSection("Memcached", "memcacheds", "configmaps") + testRule("pods", "get") +
+				testSection("Redis", "redis", "services") + testRulesMarker,
+		},
+		{
+			name:    "cluster role of a namespaced project",
+			content: testClusterRoleHead + testRulesMarker,
+			kind:    testClusterChartKind("Memcached", "memcacheds", "nodes"),
+			want:    testClusterRoleHead + testSection("Memcached", "", "nodes") + testRulesMarker,
+		},
+		{
+			name:    "cluster role of a namespaced project, kind without cluster rules",
+			content: testClusterRoleHead + testSection("Memcached", "", "nodes") + testRulesMarker,
+			kind:    testClusterChartKind("Memcached", "memcacheds"),
+			want:    testClusterRoleHead + testRulesMarker,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := UpsertRole([]byte(tt.content), tt.kind)
+			checkErr(t, err, "")
+			if string(content) != tt.want {
+				t.Errorf("expected role:\n%s\ngot:\n%s", tt.want, content)
+			}
+		})
+	}
+}
+
+func TestDiffRole(t *testing.T) {
+	const memcachedKind, redisKind = "cache.example.com/v1alpha1, Kind=Memcached",
+		"cache.example.com/v1alpha1, Kind=Redis"
+	memcached := testChartKind("Memcached", "memcacheds", "services", "configmaps")
+	tests := []struct {
+		name        string
+		content     string
+		kinds       []ChartKind
+		otherKinds  []schema.GroupVersionKind
+		wantMissing []Permission
+		wantExcess  []Permission
+		wantErr     string
+	}{
+		{
+			name:    "up to date",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services", "configmaps") + testRulesMarker,
+			kinds:   []ChartKind{memcached},
+		},
+		{
+			name:    "missing permission",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker,
+			kinds:   []ChartKind{memcached},
+			wantMissing: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "configmaps"},
+			},
+		},
+		{
+			name: "permission granted by a user rule",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") +
+				testRule("configmaps", `"*"`) + testRulesMarker,
+			kinds: []ChartKind{memcached},
+		},
+		{
+			name: "permission granted by a user rule with some verbs",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") +
+				testRule("configmaps", "get") + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			wantMissing: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "configmaps"},
+			},
+		},
+		{
+			name: "rules of a section without its header",
+			content: testRoleHead + testSectionRules("memcacheds", "services", "configmaps", "pods") + "\n" +
+				testRulesMarker,
+			kinds: []ChartKind{memcached},
+		},
+		{
+			name: "excess permission",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services", "configmaps", "pods") +
+				testRulesMarker,
+			kinds: []ChartKind{memcached},
+			wantExcess: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "pods"},
+			},
+		},
+		{
+			name: "section of a kind not in the project",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services", "configmaps") +
+				testSection("Redis", "redis", "services") + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			wantExcess: []Permission{
+				{Kind: redisKind, APIGroup: "cache.example.com", Resource: "redis"},
+				{Kind: redisKind, APIGroup: "cache.example.com", Resource: "redis/status"},
+				{Kind: redisKind, APIGroup: "cache.example.com", Resource: "redis/finalizers"},
+				{Kind: redisKind, APIGroup: "", Resource: "services"},
+			},
+		},
+		{
+			name: "section of another kind of the project",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services", "configmaps") +
+				testSection("Other", "others", "pods") + testRulesMarker,
+			kinds:      []ChartKind{memcached},
+			otherKinds: []schema.GroupVersionKind{testOtherGVK},
+		},
+		{
+			name: "roles in each watched namespace missing the same permission",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services") + testRulesMarker +
+				"---\n" + testRoleHead + testSection("Memcached", "memcacheds", "services", "pods") + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			wantMissing: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "configmaps"},
+			},
+			wantExcess: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "pods"},
+			},
+		},
+		{
+			name: "role of a watched namespace missing a permission",
+			content: testRoleHead + testSection("Memcached", "memcacheds", "services", "configmaps") +
+				testRulesMarker + "---\n" + testRoleHead + testRulesMarker,
+			kinds: []ChartKind{memcached},
+			wantMissing: []Permission{
+				{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds"},
+				{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds/status"},
+				{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds/finalizers"},
+				{Kind: memcachedKind, APIGroup: "", Resource: "services"},
+				{Kind: memcachedKind, APIGroup: "", Resource: "configmaps"},
+			},
+		},
+		{
+			name:    "cluster role of a namespaced project",
+			content: testClusterRoleHead + testRulesMarker,
+			kinds: []ChartKind{testClusterChartKind("Memcached", "memcacheds", "nodes"),
+				testClusterChartKind("Redis", "redis")},
+			wantMissing: []Permission{
+				{Kind: memcachedKind, APIGroup: "", Resource: "nodes"},
+			},
+		},
+		{
+			name:    "invalid role",
+			content: "rules: all\n",
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			missing, excess, err := DiffRole([]byte(tt.content), tt.kinds, tt.otherKinds)
+			checkErr(t, err, tt.wantErr)
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("expected missing permissions:\n%+v\ngot:\n%+v", tt.wantMissing, missing)
+			}
+			if !reflect.DeepEqual(excess, tt.wantExcess) {
+				t.Errorf("expected excess permissions:\n%+v\ngot:\n%+v", tt.wantExcess, excess)
+			}
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	rule := func(group, resource string, verbs ...string) rbacv1.PolicyRule {
+		return rbacv1.PolicyRule{APIGroups: []string{group}, Resources: []string{resource}, Verbs: verbs}
+	}
+	tests := []struct {
+		name  string
+		role  []rbacv1.PolicyRule
+		group string
+		want  bool
+	}{
+		{
+			name:  "all verbs",
+			role:  []rbacv1.PolicyRule{rule("apps", "deployments", "*")},
+			group: "apps",
+			want:  true,
+		},
+		{
+			name: "verbs of the reconciler",
+			role: []rbacv1.PolicyRule{rule("apps", "deployments", "create", "delete", "get", "list", "patch",
+				"update", "watch")},
+			group: "apps",
+			want:  true,
+		},
+		{
+			name: "verbs of the reconciler in several rules",
+			role: []rbacv1.PolicyRule{rule("apps", "deployments", "create", "delete", "get"),
+				rule("apps", "*", "list", "patch", "update", "watch")},
+			group: "apps",
+			want:  true,
+		},
+		{
+			name:  "all resources of all groups",
+			role:  []rbacv1.PolicyRule{rule("*", "*", "*")},
+			group: "apps",
+			want:  true,
+		},
+		{
+			name:  "some verbs",
+			role:  []rbacv1.PolicyRule{rule("apps", "deployments", "get", "list", "watch")},
+			group: "apps",
+		},
+		{
+			name:  "other resource",
+			role:  []rbacv1.PolicyRule{rule("apps", "statefulsets", "*")},
+			group: "apps",
+		},
+		{
+			name:  "other group",
+			role:  []rbacv1.PolicyRule{rule("extensions", "deployments", "*")},
+			group: "apps",
+		},
+		{
+			name: "no rules",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Covers(tt.role, tt.group, "deployments"); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+)
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+// newTestResource returns the resource of the cache.example.com/v1alpha1 kind.
+func newTestResource(kind, plural string) *resource.Resource {
+	return &resource.Resource{
+		GVK:    resource.GVK{Group: "cache", Domain: "example.com", Version: "v1alpha1", Kind: kind},
+		Plural: plural,
+	}
+}
+
+// newFixtureChart returns a chart rendering namespaced and cluster-scoped built-in kinds, a kind that
+// is not built-in, the same resource twice, and the files skipped when generating the rules.
+func newFixtureChart() *chart.Chart {
+	templates := map[string]string{
+		"deployment.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"service.yaml":    "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"configmaps.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}-a\n" +
+			"---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}-b\n",
+		"statefulset.yaml": "apiVersion: apps/v1\nkind: StatefulSet\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"clusterrole.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"namespace.yaml":   "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"monitor.yaml":     "apiVersion: monitoring.coreos.com/v1\nkind: ServiceMonitor\nmetadata:\n  name: {{ .Release.Name }}\n",
+		"disabled.yaml":    "{{- if .Values.enabled }}\napiVersion: v1\nkind: Secret\n{{- end }}\n",
+		"_helpers.tpl":     "{{- define \"fixture.name\" }}fixture{{ end }}\n",
+		"NOTES.txt":        "Installed {{ .Release.Name }}\n",
+	}
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "fixture", Version: "0.1.0"},
+		Values:   map[string]interface{}{"enabled": false},
+	}
+	for name, data := range templates {
+		chrt.Templates = append(chrt.Templates, &chart.File{Name: "templates/" + name, Data: []byte(data)})
+	}
+	return chrt
+}
+
+func TestChartRules(t *testing.T) {
+	clusterRules, namespacedRules, err := ChartRules(newFixtureChart(), DiscoveryStatic, "")
+	checkErr(t, err, "")
+
+	wantCluster := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"*"}},
+		{APIGroups: []string{"rbac.authorization.k8s.io"}, Resources: []string{"clusterroles"}, Verbs: []string{"*"}},
+	}
+	if !reflect.DeepEqual(clusterRules, wantCluster) {
+		t.Errorf("expected cluster rules:\n%+v\ngot:\n%+v", wantCluster, clusterRules)
+	}
+
+	section, err := renderSection(ChartKind{Resource: newTestResource("Memcached", "memcacheds"), Rules: namespacedRules})
+	checkErr(t, err, "")
+	want := `##
+## Rules for cache.example.com/v1alpha1, Kind: Memcached
+##
+- apiGroups:
+  - cache.example.com
+  resources:
+  - memcacheds
+  - memcacheds/status
+  - memcacheds/finalizers
+  verbs:
+  - create
+  - delete
+  - get
+  - list
+  - patch
+  - update
+  - watch
+- apiGroups:
+  - ""
+  resources:
+  - configmaps
+  - services
+  verbs:
+  - "*"
+- apiGroups:
+  - "apps"
+  resources:
+  - deployments
+  - statefulsets
+  verbs:
+  - "*"
+
+`
+	if section != want {
+		t.Errorf("expected rules:\n%s\ngot:\n%s", want, section)
+	}
+
+	if _, _, err := ChartRules(newFixtureChart(), "unknown", ""); err == nil {
+		t.Error("expected an error for an unknown discovery mode")
+	}
+}
+
+func TestRenderChart(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  string
+	}{
+		{
+			name:     "valid templates",
+			template: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}\n",
+		},
+		{
+			name:     "template error",
+			template: "{{ required \"name is required\" .Values.name }}\n",
+			wantErr:  "name is required",
+		},
+		{
+			name:     "template parse error",
+			template: "{{ .Values.name\n",
+			wantErr:  "failed to render chart templates",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chrt := &chart.Chart{
+				Metadata:  &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "fixture", Version: "0.1.0"},
+				Templates: []*chart.File{{Name: "templates/manifest.yaml", Data: []byte(tt.template)}},
+			}
+			checkErr(t, RenderChart(chrt), tt.wantErr)
+		})
+	}
+}
+
+func TestBuildRulesFromGroups(t *testing.T) {
+	rules := buildRulesFromGroups(map[string]map[string]struct{}{
+		"policy": {"poddisruptionbudgets": {}},
+		"apps":   {"statefulsets": {}, "deployments": {}, "daemonsets": {}},
+		"":       {"services": {}, "configmaps": {}},
+	})
+	want := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps", "services"}, Verbs: []string{"*"}},
+		{APIGroups: []string{"apps"}, Resources: []string{"daemonsets", "deployments", "statefulsets"},
+			Verbs: []string{"*"}},
+		{APIGroups: []string{"policy"}, Resources: []string{"poddisruptionbudgets"}, Verbs: []string{"*"}},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("expected rules:\n%+v\ngot:\n%+v", want, rules)
+	}
+
+	if rules := buildRulesFromGroups(nil); len(rules) != 0 {
+		t.Errorf("expected no rules, got %+v", rules)
+	}
+}
+
+func TestRoleRules(t *testing.T) {
+	secrets := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"*"}}
+	services := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"services"}, Verbs: []string{"*"}}
+	tests := []struct {
+		name    string
+		content string
+		want    [][]rbacv1.PolicyRule
+		wantErr string
+	}{
+		{
+			name: "cluster role",
+			content: "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: manager-role\n" +
+				"rules:\n- apiGroups:\n  - \"\"\n  resources:\n  - secrets\n  verbs:\n  - \"*\"\n",
+			want: [][]rbacv1.PolicyRule{{secrets}},
+		},
+		{
+			name: "role in each watched namespace",
+			content: "apiVersion: rbac.authorization.k8s.io/v1\nkind: Role\nmetadata:\n  name: manager-role-foo\n" +
+				"  namespace: foo\nrules:\n- apiGroups:\n  - \"\"\n  resources:\n  - secrets\n  verbs:\n  - \"*\"\n" +
+				"#+kubebuilder:scaffold:rules\n---\n" +
+				"apiVersion: rbac.authorization.k8s.io/v1\nkind: Role\nmetadata:\n  name: manager-role-bar\n" +
+				"  namespace: bar\nrules:\n- apiGroups:\n  - \"\"\n  resources:\n  - services\n  verbs:\n  - \"*\"\n" +
+				"#+kubebuilder:scaffold:rules\n",
+			want: [][]rbacv1.PolicyRule{{secrets}, {services}},
+		},
+		{
+			name:    "invalid role",
+			content: "rules: secrets\n",
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := RoleRules([]byte(tt.content))
+			checkErr(t, err, tt.wantErr)
+			if !reflect.DeepEqual(rules, tt.want) {
+				t.Errorf("expected rules:\n%+v\ngot:\n%+v", tt.want, rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+var _ machinery.Template = &RoleBinding{}
+
+// RoleBinding scaffolds a file that defines the role binding for the manager
+type RoleBinding struct {
+	machinery.TemplateMixin
+
+	// WatchNamespaces are the namespaces watched by the manager, where the manager roles are bound with
+	// role bindings. The manager cluster role is bound with a cluster role binding if there are none.
+	WatchNamespaces []string
+}
+
+// SetTemplateDefaults implements file.Template
+func (f *RoleBinding) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join("config", "rbac", "role_binding.yaml")
+	}
+
+	f.TemplateBody = managerBindingTemplate
+
+	// A preceding kustomize plugin scaffolds a cluster role binding
+	if len(f.WatchNamespaces) != 0 {
+		f.IfExistsAction = machinery.OverwriteFile
+	}
+
+	return nil
+}
+
+const managerBindingTemplate = `{{ range $i, $ns := .WatchNamespaces }}{{ if $i }}---
+{{ end }}apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: manager-rolebinding-{{ $ns }}
+  namespace: {{ $ns }}
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: manager-role-{{ $ns }}
+subjects:
+- kind: ServiceAccount
+  name: controller-manager
+  namespace: system
+{{ else }}apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: manager-rolebinding
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: manager-role
+subjects:
+- kind: ServiceAccount
+  name: controller-manager
+  namespace: system
+{{ end }}`
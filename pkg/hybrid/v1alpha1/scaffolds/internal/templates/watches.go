@@ -41,57 +41,10 @@ func (f *Watches) SetTemplateDefaults() error {
 	return nil
 }
 
-var _ machinery.Inserter = &WatchesUpdater{}
-
-type WatchesUpdater struct {
-	machinery.ResourceMixin
-
-	ChartPath string
-}
-
-func (*WatchesUpdater) GetPath() string {
-	return defaultWatchesFile
-}
-
-func (*WatchesUpdater) GetIfExistsAction() machinery.IfExistsAction {
-	return machinery.OverwriteFile
-}
-
 const (
 	watchMarker = "watch"
 )
 
-func (f *WatchesUpdater) GetMarkers() []machinery.Marker {
-	return []machinery.Marker{
-		machinery.NewMarkerFor(defaultWatchesFile, watchMarker),
-	}
-}
-
-func (f *WatchesUpdater) GetCodeFragments() machinery.CodeFragmentsMap {
-	fragments := make(machinery.CodeFragmentsMap, 1)
-
-	// If resource is not being provided we are creating the file, not updating it
-	if f.Resource == nil {
-		return fragments
-	}
-
-	// Generate watch fragments
-	watches := make([]string, 0)
-	watches = append(watches,
-		fmt.Sprintf(watchFragment, f.Resource.QualifiedGroup(), f.Resource.Version, f.Resource.Kind, f.ChartPath))
-
-	if len(watches) != 0 {
-		fragments[machinery.NewMarkerFor(defaultWatchesFile, watchMarker)] = watches
-	}
-	return fragments
-}
-
-const watchFragment = `- group: %s
-  version: %s
-  kind: %s
-  chart: %s
-`
-
 const watchesTemplate = `# Use the 'create api' subcommand to add watches to this file.
 %s
 `
@@ -0,0 +1,201 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watches
+
+import (
+	"path/filepath"
+
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+)
+
+// ImportPath is the import path of the package scaffolded by Loader, relative to the repository of the project
+const ImportPath = "internal/watches"
+
+var _ machinery.Template = &Loader{}
+
+// Loader scaffolds the package which loads the watches file of the project, used by main.go to set up the
+// Helm reconcilers. It is scaffolded into the project so that the project does not depend on this plugin.
+type Loader struct {
+	machinery.TemplateMixin
+	machinery.BoilerplateMixin
+}
+
+// SetTemplateDefaults implements machinery.Template
+func (f *Loader) SetTemplateDefaults() error {
+	if f.Path == "" {
+		f.Path = filepath.Join(filepath.FromSlash(ImportPath), "watches.go")
+	}
+
+	f.TemplateBody = loaderTemplate
+
+	return nil
+}
+
+// The loading and validation of the watches mirror the watches package of this plugin, which validates the
+// watches file when scaffolding it.
+const loaderTemplate = `{{ .Boilerplate }}
+
+// Package watches loads the watches.yaml file, which lists the kinds reconciled by
+// Helm reconcilers and their charts.
+package watches
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// GroupVersionKind identifies a kind in the watches file
+type GroupVersionKind struct {
+	Group   string ` + "`" + `json:"group"` + "`" + `
+	Version string ` + "`" + `json:"version"` + "`" + `
+	Kind    string ` + "`" + `json:"kind"` + "`" + `
+}
+
+// Watch configures the Helm reconciler of a kind
+type Watch struct {
+	Group     string ` + "`" + `json:"group"` + "`" + `
+	Version   string ` + "`" + `json:"version"` + "`" + `
+	Kind      string ` + "`" + `json:"kind"` + "`" + `
+	ChartPath string ` + "`" + `json:"chart"` + "`" + `
+
+	WatchDependentResources *bool                 ` + "`" + `json:"watchDependentResources,omitempty"` + "`" + `
+	OverrideValues          map[string]string     ` + "`" + `json:"overrideValues,omitempty"` + "`" + `
+	ReconcilePeriod         *metav1.Duration      ` + "`" + `json:"reconcilePeriod,omitempty"` + "`" + `
+	MaxConcurrentReconciles *int                  ` + "`" + `json:"maxConcurrentReconciles,omitempty"` + "`" + `
+	Selector                *metav1.LabelSelector ` + "`" + `json:"selector,omitempty"` + "`" + `
+	Blacklist               []GroupVersionKind    ` + "`" + `json:"blacklist,omitempty"` + "`" + `
+
+	// Selector and Blacklist are rejected by Validate, they are not supported by the Helm reconciler yet
+
+	// Chart is the chart at ChartPath, it is only set by Load
+	Chart *chart.Chart ` + "`" + `json:"-"` + "`" + `
+}
+
+// GroupVersionKind returns the kind reconciled by w
+func (w Watch) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: w.Group, Version: w.Version, Kind: w.Kind}
+}
+
+// Load loads the watches of the file at path, validates them, and loads their
+// charts. Relative chart paths are resolved against chartsDir, or the working
+// directory if it is empty, and environment variables referenced by the override
+// values are expanded.
+func Load(path, chartsDir string) ([]Watch, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	watches, err := Parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid watches file %s: %w", path, err)
+	}
+
+	for i, w := range watches {
+		chartPath := w.ChartPath
+		if !filepath.IsAbs(chartPath) {
+			chartPath = filepath.Join(chartsDir, chartPath)
+		}
+		if info, err := os.Stat(chartPath); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("invalid watch %s: chart directory %s does not exist",
+				w.GroupVersionKind(), chartPath)
+		}
+		w.Chart, err = loader.Load(chartPath)
+		if err != nil {
+			return nil, fmt.Errorf("invalid watch %s: invalid chart %s: %w", w.GroupVersionKind(), chartPath, err)
+		}
+		w.OverrideValues = expandOverrideEnvs(w.OverrideValues)
+		watches[i] = w
+	}
+	return watches, nil
+}
+
+// Parse decodes and validates the watches of a watches file. Unknown fields are
+// rejected, so that a misspelled option is not silently ignored.
+func Parse(data []byte) ([]Watch, error) {
+	watches := []Watch{}
+	if err := yaml.UnmarshalStrict(data, &watches); err != nil {
+		return nil, err
+	}
+	if err := Validate(watches); err != nil {
+		return nil, err
+	}
+	return watches, nil
+}
+
+// Validate verifies the configuration of every watch, and that no kind is watched twice
+func Validate(watches []Watch) error {
+	gvks := make(map[schema.GroupVersionKind]bool, len(watches))
+	for _, w := range watches {
+		gvk := w.GroupVersionKind()
+		if err := verifyWatch(w); err != nil {
+			return fmt.Errorf("invalid watch %s: %w", gvk, err)
+		}
+		if gvks[gvk] {
+			return fmt.Errorf("duplicate GVK: %s", gvk)
+		}
+		gvks[gvk] = true
+	}
+	return nil
+}
+
+func verifyWatch(w Watch) error {
+	// A GVK without a group is valid, as for the core kinds
+	if w.Version == "" {
+		return errors.New("version must not be empty")
+	}
+	if w.Kind == "" {
+		return errors.New("kind must not be empty")
+	}
+	if w.ChartPath == "" {
+		return errors.New("chart must not be empty")
+	}
+	if w.ReconcilePeriod != nil && w.ReconcilePeriod.Duration < 0 {
+		return fmt.Errorf("reconcilePeriod %s must not be negative", w.ReconcilePeriod.Duration)
+	}
+	if w.MaxConcurrentReconciles != nil && *w.MaxConcurrentReconciles < 1 {
+		return fmt.Errorf("maxConcurrentReconciles %d must be at least 1", *w.MaxConcurrentReconciles)
+	}
+	// The Helm reconciler has no option to restrict the custom resources or the dependent resources it
+	// watches, so the manager would reconcile the resources they exclude
+	if w.Selector != nil {
+		return errors.New("selector is not supported by the Helm reconciler")
+	}
+	if len(w.Blacklist) != 0 {
+		return errors.New("blacklist is not supported by the Helm reconciler")
+	}
+	return nil
+}
+
+func expandOverrideEnvs(in map[string]string) map[string]string {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = os.ExpandEnv(v)
+	}
+	return out
+}
+`
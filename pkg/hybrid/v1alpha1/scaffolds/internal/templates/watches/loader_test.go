@@ -0,0 +1,65 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watches
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/watches"
+)
+
+// TestLoaderTypes checks that the types of the scaffolded loader decode the same
+// watches files as the watches package of the plugin, which writes them.
+func TestLoaderTypes(t *testing.T) {
+	src := strings.Replace(loaderTemplate, "{{ .Boilerplate }}", "", 1)
+	file, err := parser.ParseFile(token.NewFileSet(), "watches.go", src, 0)
+	if err != nil {
+		t.Fatalf("invalid Go source: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		typ  reflect.Type
+	}{
+		{name: "Watch", typ: reflect.TypeOf(watches.Watch{})},
+		{name: "GroupVersionKind", typ: reflect.TypeOf(watches.GroupVersionKind{})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []string{}
+			for i := 0; i < tt.typ.NumField(); i++ {
+				f := tt.typ.Field(i)
+				want = append(want, f.Name+" "+string(f.Tag))
+			}
+
+			fields := []string{}
+			obj := file.Scope.Lookup(tt.name)
+			if obj == nil {
+				t.Fatalf("type %s not found", tt.name)
+			}
+			for _, f := range obj.Decl.(*ast.TypeSpec).Type.(*ast.StructType).Fields.List {
+				fields = append(fields, f.Names[0].Name+" "+strings.Trim(f.Tag.Value, "`"))
+			}
+			if !reflect.DeepEqual(fields, want) {
+				t.Errorf("expected fields:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(fields, "\n"))
+			}
+		})
+	}
+}
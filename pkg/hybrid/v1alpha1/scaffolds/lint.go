@@ -0,0 +1,240 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/chartutil"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/rbac"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/watches"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// Checks run by Lint, reported in the problems they find.
+const (
+	LintCheckWatches = "watches"
+	LintCheckCRD     = "crd"
+	LintCheckChart   = "chart"
+	LintCheckRBAC    = "rbac"
+	LintCheckMain    = "main"
+)
+
+var (
+	crdBasesDir = filepath.Join("config", "crd", "bases")
+	roleFile    = filepath.Join("config", "rbac", "role.yaml")
+	// clusterRoleFile only exists in the projects whose manager only watches some namespaces
+	clusterRoleFile = filepath.Join("config", "rbac", "cluster_role.yaml")
+)
+
+// LintProblem is an inconsistency between the files of a project found by Lint
+type LintProblem struct {
+	// Check is the check that found the problem
+	Check string `json:"check"`
+	// File is the file the problem must be fixed in
+	File string `json:"file"`
+	// Message describes the problem
+	Message string `json:"message"`
+}
+
+func (p LintProblem) String() string {
+	return fmt.Sprintf("%s: %s [%s]", p.File, p.Message, p.Check)
+}
+
+// Lint cross-checks the files of the project at the root of fs:
+//   - every watch of watches.yaml has a chart directory, whose chart renders, and a CRD in config/crd
+//   - config/rbac/role.yaml, and config/rbac/cluster_role.yaml for the cluster-scoped resources if the
+//     project has it, grant the rules needed to manage the resources rendered by the charts, computed
+//     as configured by rbacOptions
+//   - main.go has the scaffold markers used by create api and create webhook
+//
+// Errors are only returned if the checks can not be run.
+func Lint(fs afero.Fs, rbacOptions RBACOptions) ([]LintProblem, error) {
+	if _, err := fs.Stat("PROJECT"); err != nil {
+		return nil, fmt.Errorf("no PROJECT file found, lint must be run from the root of a project: %v", err)
+	}
+
+	var problems []LintProblem
+
+	crds, err := loadCRDs(fs)
+	if err != nil {
+		return nil, err
+	}
+	role, roleProblem := lintRole(fs, roleFile)
+	if roleProblem != nil {
+		problems = append(problems, *roleProblem)
+	}
+	// The rules of the cluster-scoped resources are in role.yaml, unless the project has cluster_role.yaml
+	clusterRulesFile, clusterRole, clusterRoleProblem := roleFile, role, roleProblem
+	if _, err := fs.Stat(clusterRoleFile); err == nil {
+		clusterRulesFile = clusterRoleFile
+		clusterRole, clusterRoleProblem = lintRole(fs, clusterRoleFile)
+		if clusterRoleProblem != nil {
+			problems = append(problems, *clusterRoleProblem)
+		}
+	}
+
+	ws, watchesProblem := lintWatchesFile(fs)
+	if watchesProblem != nil {
+		problems = append(problems, *watchesProblem)
+	}
+	for _, w := range ws {
+		gvk := w.GroupVersionKind()
+		if _, ok := crds[gvk]; !ok {
+			problems = append(problems, LintProblem{Check: LintCheckCRD, File: crdBasesDir,
+				Message: fmt.Sprintf("no CRD defines %s, watched in %s", gvk, watchesFile)})
+		}
+
+		if info, err := fs.Stat(w.ChartPath); err != nil || !info.IsDir() {
+			problems = append(problems, LintProblem{Check: LintCheckChart, File: watchesFile,
+				Message: fmt.Sprintf("chart directory %s of %s does not exist", w.ChartPath, gvk)})
+			continue
+		}
+		chrt, err := chartutil.LoadChartDir(fs, w.ChartPath)
+		if err != nil {
+			problems = append(problems, LintProblem{Check: LintCheckChart, File: w.ChartPath,
+				Message: fmt.Sprintf("invalid chart of %s: %v", gvk, err)})
+			continue
+		}
+		if err := rbac.RenderChart(chrt); err != nil {
+			problems = append(problems, LintProblem{Check: LintCheckChart, File: w.ChartPath,
+				Message: fmt.Sprintf("chart of %s does not render with its default values: %v", gvk, err)})
+			continue
+		}
+
+		// The rules can not be checked without the roles
+		if roleProblem != nil || clusterRoleProblem != nil {
+			continue
+		}
+		clusterRules, namespacedRules, err := rbac.ChartRules(chrt, rbacOptions.Discovery, rbacOptions.ResourcesFile)
+		if err != nil {
+			problems = append(problems, LintProblem{Check: LintCheckRBAC, File: w.ChartPath,
+				Message: fmt.Sprintf("unable to compute the RBAC rules of the chart of %s: %v", gvk, err)})
+			continue
+		}
+		problems = append(problems, lintRules(roleFile, role, namespacedRules, gvk)...)
+		problems = append(problems, lintRules(clusterRulesFile, clusterRole, clusterRules, gvk)...)
+	}
+
+	problems = append(problems, lintMain(fs)...)
+
+	return problems, nil
+}
+
+// lintWatchesFile returns the watches of watches.yaml, or a problem if it is not valid
+func lintWatchesFile(fs afero.Fs) ([]watches.Watch, *LintProblem) {
+	b, err := afero.ReadFile(fs, watchesFile)
+	if err != nil {
+		return nil, &LintProblem{Check: LintCheckWatches, File: watchesFile, Message: err.Error()}
+	}
+	ws, err := watches.Parse(b)
+	if err != nil {
+		return nil, &LintProblem{Check: LintCheckWatches, File: watchesFile, Message: err.Error()}
+	}
+	return ws, nil
+}
+
+// loadCRDs returns the files of the CRDs in config/crd/bases, by the kinds they define
+func loadCRDs(fs afero.Fs) (map[schema.GroupVersionKind]string, error) {
+	crds := map[schema.GroupVersionKind]string{}
+
+	files, err := afero.ReadDir(fs, crdBasesDir)
+	if os.IsNotExist(err) {
+		return crds, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading CRDs: %v", err)
+	}
+	for _, info := range files {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".yaml" {
+			continue
+		}
+		path := filepath.Join(crdBasesDir, info.Name())
+		b, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CRD: %v", err)
+		}
+		crd := apiextensionsv1.CustomResourceDefinition{}
+		if err := yaml.Unmarshal(b, &crd); err != nil || crd.Kind != "CustomResourceDefinition" {
+			continue
+		}
+		for _, v := range crd.Spec.Versions {
+			crds[schema.GroupVersionKind{Group: crd.Spec.Group, Version: v.Name, Kind: crd.Spec.Names.Kind}] = path
+		}
+	}
+	return crds, nil
+}
+
+// lintRole returns the rules of each role at path, or a problem if they can not be read. role.yaml has a
+// role in each watched namespace if the manager only watches some namespaces.
+func lintRole(fs afero.Fs, path string) ([][]rbacv1.PolicyRule, *LintProblem) {
+	b, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, &LintProblem{Check: LintCheckRBAC, File: path, Message: err.Error()}
+	}
+	roles, err := rbac.RoleRules(b)
+	if err != nil {
+		return nil, &LintProblem{Check: LintCheckRBAC, File: path, Message: err.Error()}
+	}
+	return roles, nil
+}
+
+// lintRules returns a problem for each resource of rules, rendered by the chart of gvk, that one of roles,
+// read from path, does not grant
+func lintRules(path string, roles [][]rbacv1.PolicyRule, rules []rbacv1.PolicyRule,
+	gvk schema.GroupVersionKind) []LintProblem {
+	var problems []LintProblem
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, res := range rule.Resources {
+				for _, role := range roles {
+					if !rbac.Covers(role, group, res) {
+						problems = append(problems, LintProblem{Check: LintCheckRBAC, File: path,
+							Message: fmt.Sprintf("missing rule for %q in API group %q, rendered by the chart of %s",
+								res, group, gvk)})
+						break
+					}
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// lintMain checks that main.go has the markers create api and create webhook insert code at
+func lintMain(fs afero.Fs) []LintProblem {
+	updater := &templates.MainUpdater{}
+	b, err := afero.ReadFile(fs, updater.GetPath())
+	if err != nil {
+		return []LintProblem{{Check: LintCheckMain, File: updater.GetPath(), Message: err.Error()}}
+	}
+
+	var problems []LintProblem
+	for _, marker := range updater.GetMarkers() {
+		if !strings.Contains(string(b), marker.String()) {
+			problems = append(problems, LintProblem{Check: LintCheckMain, File: updater.GetPath(),
+				Message: fmt.Sprintf("missing scaffold marker %q", marker.String())})
+		}
+	}
+	return problems
+}
@@ -0,0 +1,264 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+)
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+// writeFiles returns an in-memory filesystem with files, keyed by their slash separated path.
+func writeFiles(t *testing.T, files map[string]string) afero.Fs {
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		if err := afero.WriteFile(fs, filepath.FromSlash(path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fs
+}
+
+// testMain returns a main.go with the scaffold markers, without the ones in skip.
+func testMain(skip ...string) string {
+	lines := []string{"package main", ""}
+	for _, m := range (&templates.MainUpdater{}).GetMarkers() {
+		if !contains(skip, m.String()) {
+			lines = append(lines, "\t"+m.String())
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// testCRD returns the CRD of the kind of the cache.example.com/v1alpha1 group.
+func testCRD(kind, plural string) string {
+	return `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: ` + plural + `.cache.example.com
+spec:
+  group: cache.example.com
+  names:
+    kind: ` + kind + `
+    plural: ` + plural + `
+  scope: Namespaced
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+`
+}
+
+const (
+	testWatches = `- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached
+#+kubebuilder:scaffold:watch
+`
+
+	testChart       = "apiVersion: v2\nname: memcached\nversion: 0.1.0\n"
+	testDeployment  = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: {{ .Release.Name }}\n"
+	testService     = "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .Release.Name }}\n"
+	testClusterRBAC = "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n" +
+		"  name: {{ .Release.Name }}\n"
+
+	testRole = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: manager-role
+rules:
+- apiGroups:
+  - ""
+  resources:
+  - services
+  verbs:
+  - "*"
+- apiGroups:
+  - apps
+  resources:
+  - deployments
+  verbs:
+  - "*"
+#+kubebuilder:scaffold:rules
+`
+)
+
+// testProject returns the files of a consistent project with the Memcached kind, updated with files.
+// The files with an empty content are removed.
+func testProject(files map[string]string) map[string]string {
+	project := map[string]string{
+		"PROJECT":      "domain: example.com\n",
+		"main.go":      testMain(),
+		"watches.yaml": testWatches,
+		"config/crd/bases/cache.example.com_memcacheds.yaml": testCRD("Memcached", "memcacheds"),
+		"config/rbac/role.yaml":                              testRole,
+		"helm-charts/memcached/Chart.yaml":                   testChart,
+		"helm-charts/memcached/templates/deployment.yaml":    testDeployment,
+		"helm-charts/memcached/templates/service.yaml":       testService,
+	}
+	for path, content := range files {
+		if content == "" {
+			delete(project, path)
+		} else {
+			project[path] = content
+		}
+	}
+	return project
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []LintProblem
+		wantErr string
+	}{
+		{
+			name:  "consistent project",
+			files: testProject(nil),
+		},
+		{
+			name: "consistent namespaced project",
+			files: testProject(map[string]string{
+				"helm-charts/memcached/templates/clusterrole.yaml": testClusterRBAC,
+				"config/rbac/role.yaml": strings.Replace(testRole, "kind: ClusterRole", "kind: Role", 1) +
+					"---\n" + strings.Replace(testRole, "kind: ClusterRole", "kind: Role", 1),
+				"config/rbac/cluster_role.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\n" +
+					"metadata:\n  name: manager-cluster-role\nrules:\n- apiGroups:\n  - rbac.authorization.k8s.io\n" +
+					"  resources:\n  - clusterroles\n  verbs:\n  - \"*\"\n",
+			}),
+		},
+		{
+			name:    "not a project",
+			files:   testProject(map[string]string{"PROJECT": ""}),
+			wantErr: "no PROJECT file found, lint must be run from the root of a project",
+		},
+		{
+			name: "watched kind without a CRD or a chart",
+			files: testProject(map[string]string{
+				"watches.yaml": testWatches + `- group: cache.example.com
+  version: v1alpha1
+  kind: Redis
+  chart: helm-charts/redis
+`,
+			}),
+			want: []LintProblem{
+				{Check: LintCheckCRD, File: crdBasesDir,
+					Message: "no CRD defines cache.example.com/v1alpha1, Kind=Redis, watched in watches.yaml"},
+				{Check: LintCheckChart, File: "watches.yaml",
+					Message: "chart directory helm-charts/redis of cache.example.com/v1alpha1, Kind=Redis does not exist"},
+			},
+		},
+		{
+			name:  "invalid watches file",
+			files: testProject(map[string]string{"watches.yaml": "- kind: Memcached\n"}),
+			want: []LintProblem{
+				{Check: LintCheckWatches, File: "watches.yaml",
+					Message: "invalid watch /, Kind=Memcached: version must not be empty"},
+			},
+		},
+		{
+			name: "chart that does not render",
+			files: testProject(map[string]string{
+				"helm-charts/memcached/templates/service.yaml": `{{ fail "no service" }}`,
+			}),
+			want: []LintProblem{
+				{Check: LintCheckChart, File: "helm-charts/memcached",
+					Message: "chart of cache.example.com/v1alpha1, Kind=Memcached does not render with its default " +
+						"values: failed to render chart templates: template: memcached/templates/service.yaml:1:3: " +
+						`executing "memcached/templates/service.yaml" at <fail "no service">: error calling fail: no service`},
+			},
+		},
+		{
+			name: "missing rules",
+			files: testProject(map[string]string{
+				"helm-charts/memcached/templates/clusterrole.yaml": testClusterRBAC,
+				"config/rbac/role.yaml": strings.Replace(testRole, "  - services\n",
+					"  - configmaps\n", 1),
+			}),
+			want: []LintProblem{
+				{Check: LintCheckRBAC, File: roleFile, Message: `missing rule for "services" in API group "", ` +
+					"rendered by the chart of cache.example.com/v1alpha1, Kind=Memcached"},
+				{Check: LintCheckRBAC, File: roleFile, Message: `missing rule for "clusterroles" in API group ` +
+					`"rbac.authorization.k8s.io", rendered by the chart of cache.example.com/v1alpha1, Kind=Memcached`},
+			},
+		},
+		{
+			name: "rules missing from the role of a watched namespace",
+			files: testProject(map[string]string{
+				"config/rbac/role.yaml": testRole + "---\n" + strings.Replace(testRole, "  - deployments\n", "", 1),
+				"config/rbac/cluster_role.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\n" +
+					"metadata:\n  name: manager-cluster-role\nrules: []\n",
+			}),
+			want: []LintProblem{
+				{Check: LintCheckRBAC, File: roleFile, Message: `missing rule for "deployments" in API group ` +
+					`"apps", rendered by the chart of cache.example.com/v1alpha1, Kind=Memcached`},
+			},
+		},
+		{
+			name:  "invalid role",
+			files: testProject(map[string]string{"config/rbac/role.yaml": "rules: all\n"}),
+			want: []LintProblem{
+				{Check: LintCheckRBAC, File: roleFile, Message: "error unmarshaling JSON: while decoding JSON: json: " +
+					"cannot unmarshal string into Go struct field .rules of type []v1.PolicyRule"},
+			},
+		},
+		{
+			name:  "main.go without a marker",
+			files: testProject(map[string]string{"main.go": testMain("//+kubebuilder:scaffold:builder")}),
+			want: []LintProblem{
+				{Check: LintCheckMain, File: "main.go",
+					Message: `missing scaffold marker "//+kubebuilder:scaffold:builder"`},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := Lint(writeFiles(t, tt.files), RBACOptions{Discovery: RBACDiscoveryStatic})
+			checkErr(t, err, tt.wantErr)
+			if !reflect.DeepEqual(problems, tt.want) {
+				t.Errorf("expected problems:\n%v\ngot:\n%v", tt.want, problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/chartutil"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/rbac"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/watches"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlstore "sigs.k8s.io/kubebuilder/v3/pkg/config/store/yaml"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+)
+
+// RBACPermission is an API resource needed by the chart of a kind, or granted by its rules in role.yaml
+type RBACPermission = rbac.Permission
+
+// RBACDrift is the difference between the manager roles in config/rbac and the rules needed by the
+// current charts
+type RBACDrift struct {
+	// Missing are the permissions needed by a chart that the roles do not grant
+	Missing []RBACPermission `json:"missing"`
+	// Excess are the permissions granted by the rules generated for a kind that no chart needs
+	Excess []RBACPermission `json:"excess"`
+}
+
+// CheckRBAC compares config/rbac/role.yaml, and config/rbac/cluster_role.yaml if the manager only watches
+// some namespaces, in the project at the root of fs, with the rules needed by the charts of the kinds
+// in watches.yaml. The rules are computed as when the kinds are created, as configured by rbacOptions.
+func CheckRBAC(fs afero.Fs, rbacOptions RBACOptions) (RBACDrift, error) {
+	roles, otherKinds, err := chartKinds(fs, rbacOptions)
+	if err != nil {
+		return RBACDrift{}, err
+	}
+
+	drift := RBACDrift{}
+	for _, r := range roles {
+		content, err := afero.ReadFile(fs, r.file)
+		if err != nil {
+			return RBACDrift{}, fmt.Errorf("error reading %s: %v", r.file, err)
+		}
+		missing, excess, err := rbac.DiffRole(content, r.kinds, otherKinds)
+		if err != nil {
+			return RBACDrift{}, fmt.Errorf("error comparing %s: %v", r.file, err)
+		}
+		drift.Missing = append(drift.Missing, missing...)
+		drift.Excess = append(drift.Excess, excess...)
+	}
+	return drift, nil
+}
+
+// SyncRBAC generates again the rules of the kinds in watches.yaml in config/rbac/role.yaml, and in
+// config/rbac/cluster_role.yaml if the manager only watches some namespaces, in the project at the root
+// of fs, and removes the rules of the kinds that are not in the project. The other rules are kept. It
+// returns false if the roles were already up to date.
+func SyncRBAC(fs afero.Fs, rbacOptions RBACOptions) (bool, error) {
+	roles, otherKinds, err := chartKinds(fs, rbacOptions)
+	if err != nil {
+		return false, err
+	}
+
+	updated := false
+	for _, r := range roles {
+		content, err := afero.ReadFile(fs, r.file)
+		if err != nil {
+			return false, fmt.Errorf("error reading %s: %v", r.file, err)
+		}
+		synced, err := rbac.SyncRole(content, r.kinds, otherKinds)
+		if err != nil {
+			return false, fmt.Errorf("error updating %s: %v", r.file, err)
+		}
+		if bytes.Equal(content, synced) {
+			continue
+		}
+		if err := afero.WriteFile(fs, r.file, synced, 0644); err != nil {
+			return false, fmt.Errorf("error writing %s: %v", r.file, err)
+		}
+		updated = true
+	}
+	return updated, nil
+}
+
+// roleKinds are the chart-backed kinds, as they are scaffolded in a role file
+type roleKinds struct {
+	file  string
+	kinds []rbac.ChartKind
+}
+
+// chartKinds returns the kinds of watches.yaml with the rules needed by their charts, in role.yaml and
+// in cluster_role.yaml if the project has it, and the other kinds of the project
+func chartKinds(fs afero.Fs, rbacOptions RBACOptions) ([]roleKinds, []schema.GroupVersionKind, error) {
+	store := yamlstore.New(machinery.Filesystem{FS: fs})
+	if err := store.Load(); err != nil {
+		return nil, nil, fmt.Errorf("unable to load the PROJECT file, the command must be run from "+
+			"the root of a project: %v", err)
+	}
+	resources, err := store.Config().GetResources()
+	if err != nil {
+		return nil, nil, err
+	}
+	namespaced, err := afero.Exists(fs, clusterRoleFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking for %s: %v", clusterRoleFile, err)
+	}
+
+	b, err := afero.ReadFile(fs, watchesFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading %s: %v", watchesFile, err)
+	}
+	ws, err := watches.Parse(b)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s: %v", watchesFile, err)
+	}
+
+	watched := map[schema.GroupVersionKind]bool{}
+	role := roleKinds{file: roleFile}
+	clusterRole := roleKinds{file: clusterRoleFile}
+	for _, w := range ws {
+		gvk := w.GroupVersionKind()
+		watched[gvk] = true
+
+		// Kinds missing from the PROJECT file have the regular plural
+		res := &resource.Resource{
+			GVK:    resource.GVK{Group: w.Group, Version: w.Version, Kind: w.Kind},
+			Plural: resource.RegularPlural(w.Kind),
+		}
+		for i := range resources {
+			if resourceGVK(resources[i]) == gvk {
+				res = &resources[i]
+				break
+			}
+		}
+
+		chrt, err := chartutil.LoadChartDir(fs, w.ChartPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid chart %s of %s: %v", w.ChartPath, gvk, err)
+		}
+		clusterRules, namespacedRules, err := rbac.ChartRules(chrt, rbacOptions.Discovery, rbacOptions.ResourcesFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to compute the RBAC rules of the chart of %s: %v", gvk, err)
+		}
+		roleKind, clusterRoleKind := scopeChartKind(res, clusterRules, namespacedRules, namespaced)
+		role.kinds = append(role.kinds, roleKind)
+		clusterRole.kinds = append(clusterRole.kinds, clusterRoleKind)
+	}
+
+	var otherKinds []schema.GroupVersionKind
+	for _, res := range resources {
+		if gvk := resourceGVK(res); !watched[gvk] {
+			otherKinds = append(otherKinds, gvk)
+		}
+	}
+	if !namespaced {
+		return []roleKinds{role}, otherKinds, nil
+	}
+	return []roleKinds{role, clusterRole}, otherKinds, nil
+}
+
+// scopeChartKind returns the chart-backed kind res, with the rules of its chart, as it is scaffolded in
+// role.yaml and, if the manager only watches some namespaces, in cluster_role.yaml. role.yaml has a Role in
+// each watched namespace in that case, so the rules of the cluster-scoped resources, and of the custom
+// resources of res if they are cluster-scoped, are in cluster_role.yaml instead.
+func scopeChartKind(res *resource.Resource, clusterRules, namespacedRules []rbacv1.PolicyRule,
+	namespaced bool) (roleKind, clusterRoleKind rbac.ChartKind) {
+	if !namespaced {
+		rules := append(append([]rbacv1.PolicyRule{}, clusterRules...), namespacedRules...)
+		return rbac.ChartKind{Resource: res, Rules: rules}, rbac.ChartKind{}
+	}
+	clusterScoped := res.API != nil && !res.API.Namespaced
+	return rbac.ChartKind{Resource: res, Rules: namespacedRules, OmitResourceRules: clusterScoped},
+		rbac.ChartKind{Resource: res, Rules: clusterRules, OmitResourceRules: !clusterScoped}
+}
+
+func resourceGVK(res resource.Resource) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: res.QualifiedGroup(), Version: res.Version, Kind: res.Kind}
+}
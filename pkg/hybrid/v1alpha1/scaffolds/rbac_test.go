@@ -0,0 +1,135 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	// The PROJECT file of version 3 is loaded by the CLI, which registers its config
+	_ "sigs.k8s.io/kubebuilder/v3/pkg/config/v3"
+)
+
+const testProjectFile = `domain: example.com
+layout:
+- hybrid.helm.sdk.operatorframework.io/v1-alpha
+projectName: memcached-operator
+repo: example.com/memcached-operator
+version: "3"
+`
+
+func TestCheckAndSyncRBAC(t *testing.T) {
+	const memcachedKind = "cache.example.com/v1alpha1, Kind=Memcached"
+	memcachedPermissions := []RBACPermission{
+		{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds"},
+		{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds/status"},
+		{Kind: memcachedKind, APIGroup: "cache.example.com", Resource: "memcacheds/finalizers"},
+	}
+	namespacedRole := strings.Replace(testRole, "kind: ClusterRole", "kind: Role", 1)
+	tests := []struct {
+		name      string
+		files     map[string]string
+		want      RBACDrift
+		wantFiles []string
+		wantErr   string
+	}{
+		{
+			name:      "role without the section of a kind",
+			files:     testProject(map[string]string{"PROJECT": testProjectFile}),
+			want:      RBACDrift{Missing: memcachedPermissions},
+			wantFiles: []string{roleFile},
+		},
+		{
+			name: "section of a kind not in the project",
+			files: testProject(map[string]string{
+				"PROJECT": testProjectFile,
+				"config/rbac/role.yaml": strings.Replace(testRole, "#+kubebuilder:scaffold:rules",
+					"##\n## Rules for cache.example.com/v1alpha1, Kind: Redis\n##\n"+
+						"- apiGroups:\n  - apps\n  resources:\n  - statefulsets\n  verbs:\n  - \"*\"\n\n"+
+						"#+kubebuilder:scaffold:rules", 1),
+			}),
+			want: RBACDrift{
+				Missing: memcachedPermissions,
+				Excess: []RBACPermission{
+					{Kind: "cache.example.com/v1alpha1, Kind=Redis", APIGroup: "apps", Resource: "statefulsets"},
+				},
+			},
+			wantFiles: []string{roleFile},
+		},
+		{
+			name: "namespaced project",
+			files: testProject(map[string]string{
+				"PROJECT": testProjectFile,
+				"helm-charts/memcached/templates/clusterrole.yaml": testClusterRBAC,
+				"config/rbac/role.yaml":                            namespacedRole + "---\n" + namespacedRole,
+				"config/rbac/cluster_role.yaml": "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\n" +
+					"metadata:\n  name: manager-cluster-role\nrules:\n#+kubebuilder:scaffold:rules\n",
+			}),
+			want: RBACDrift{Missing: append(memcachedPermissions, RBACPermission{Kind: memcachedKind,
+				APIGroup: "rbac.authorization.k8s.io", Resource: "clusterroles"})},
+			wantFiles: []string{roleFile, clusterRoleFile},
+		},
+		{
+			name:    "not a project",
+			files:   testProject(map[string]string{"PROJECT": ""}),
+			wantErr: "unable to load the PROJECT file",
+		},
+		{
+			name: "invalid chart",
+			files: testProject(map[string]string{
+				"PROJECT":                          testProjectFile,
+				"helm-charts/memcached/Chart.yaml": "name: memcached\n",
+			}),
+			wantErr: "invalid chart helm-charts/memcached of " + memcachedKind,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := writeFiles(t, tt.files)
+			rbacOptions := RBACOptions{Discovery: RBACDiscoveryStatic}
+
+			drift, err := CheckRBAC(fs, rbacOptions)
+			checkErr(t, err, tt.wantErr)
+			if !reflect.DeepEqual(drift, tt.want) {
+				t.Errorf("expected drift:\n%+v\ngot:\n%+v", tt.want, drift)
+			}
+			updated, err := SyncRBAC(fs, rbacOptions)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+
+			if !updated {
+				t.Error("expected the roles to be updated")
+			}
+			for _, path := range tt.wantFiles {
+				b, err := afero.ReadFile(fs, path)
+				checkErr(t, err, "")
+				sections := strings.Count(string(b), "## Rules for cache.example.com/v1alpha1, Kind: Memcached")
+				if roles := strings.Count(string(b), "apiVersion:"); sections != roles {
+					t.Errorf("expected a section for Memcached in each role of %s, got:\n%s", path, b)
+				}
+			}
+			if drift, err := CheckRBAC(fs, rbacOptions); err != nil || !reflect.DeepEqual(drift, RBACDrift{}) {
+				t.Errorf("expected no drift after sync, got %+v, %v", drift, err)
+			}
+			if updated, err := SyncRBAC(fs, rbacOptions); err != nil || updated {
+				t.Errorf("expected the roles to be up to date after sync, got %t, %v", updated, err)
+			}
+		})
+	}
+}
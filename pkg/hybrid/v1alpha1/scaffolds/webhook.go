@@ -0,0 +1,122 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scaffolds
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/api"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/certmanager"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/crd"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/hack"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/kdefault"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds/internal/templates/webhook"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugins"
+)
+
+var _ plugins.Scaffolder = &webhookScaffolder{}
+
+// webhookScaffolder contains configuration for generating scaffolding for the
+// webhooks of a kind reconciled by a Go controller.
+type webhookScaffolder struct {
+	// fs is the filesystem that will be used by the scaffolder
+	fs machinery.Filesystem
+
+	config   config.Config
+	resource resource.Resource
+
+	// force indicates whether to overwrite existing files
+	force bool
+}
+
+// NewWebhookScaffolder returns a new plugins.Scaffolder for webhook creation operations
+func NewWebhookScaffolder(config config.Config, res resource.Resource, force bool) plugins.Scaffolder {
+	return &webhookScaffolder{
+		config:   config,
+		resource: res,
+		force:    force,
+	}
+}
+
+// InjectFS implements plugins.Scaffolder
+func (s *webhookScaffolder) InjectFS(fs machinery.Filesystem) {
+	s.fs = fs
+}
+
+// Scaffold implements plugins.Scaffolder
+func (s *webhookScaffolder) Scaffold() error {
+	fmt.Println("Writing scaffold for you to edit...")
+
+	boilerplate, err := afero.ReadFile(s.fs.FS, hack.DefaultBoilerplatePath)
+	if err != nil {
+		return fmt.Errorf("error reading boilerplate: %v", err)
+	}
+
+	// Keep track of these values before the update
+	doConversion := s.resource.HasConversionWebhook()
+
+	if err := s.config.UpdateResource(s.resource); err != nil {
+		return fmt.Errorf("error updating resource: %v", err)
+	}
+
+	// Initialize the machinery.Scaffold that will write the files to disk
+	scaffold := machinery.NewScaffold(s.fs,
+		machinery.WithConfig(s.config),
+		machinery.WithBoilerplate(string(boilerplate)),
+		machinery.WithResource(&s.resource),
+	)
+
+	if err := scaffold.Execute(
+		&api.Webhook{Force: s.force},
+		&templates.MainUpdater{WireWebhook: true},
+	); err != nil {
+		return fmt.Errorf("error scaffolding webhook: %v", err)
+	}
+
+	// The webhook server and its cert-manager certificate, enabled in config/default
+	if err := scaffold.Execute(
+		&kdefault.WebhookCAInjectionPatch{},
+		&kdefault.ManagerWebhookPatch{},
+		&webhook.Kustomization{Force: s.force},
+		&webhook.KustomizeConfig{},
+		&webhook.Service{},
+		&certmanager.Certificate{},
+		&certmanager.Kustomization{},
+		&certmanager.KustomizeConfig{},
+	); err != nil {
+		return fmt.Errorf("error scaffolding webhook kustomize files: %v", err)
+	}
+
+	if doConversion {
+		if err := scaffold.Execute(
+			&crd.WebhookPatch{},
+			&crd.CAInjectionPatch{},
+			&crd.KustomizeConfig{},
+			&crd.Kustomization{Conversion: true},
+		); err != nil {
+			return fmt.Errorf("error scaffolding conversion webhook patches: %v", err)
+		}
+
+		fmt.Println(`Webhook server has been set up for you.
+You need to implement the conversion.Hub and conversion.Convertible interfaces for your CRD types.`)
+	}
+
+	return nil
+}
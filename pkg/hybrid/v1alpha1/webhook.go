@@ -0,0 +1,152 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	sdkutil "github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/util"
+	"github.com/varshaprasad96/hybrid-helm-plugin/pkg/hybrid/v1alpha1/scaffolds"
+	"sigs.k8s.io/kubebuilder/v3/pkg/config"
+	"sigs.k8s.io/kubebuilder/v3/pkg/machinery"
+	"sigs.k8s.io/kubebuilder/v3/pkg/model/resource"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin"
+	"sigs.k8s.io/kubebuilder/v3/pkg/plugin/util"
+)
+
+// defaultWebhookVersion is the {Mutating,Validating}WebhookConfiguration API version scaffolded for new webhooks.
+const defaultWebhookVersion = "v1"
+
+type createWebhookSubcommand struct {
+	config config.Config
+
+	// For help text
+	commandName string
+
+	resource *resource.Resource
+
+	// webhooks to scaffold
+	defaulting bool
+	validation bool
+	conversion bool
+
+	// force indicates that the webhooks should be created even if they already exist
+	force bool
+}
+
+var _ plugin.CreateWebhookSubcommand = &createWebhookSubcommand{}
+
+// UpdateMetadata defines plugin context
+func (p *createWebhookSubcommand) UpdateMetadata(cliMeta plugin.CLIMetadata, subcmdMeta *plugin.SubcommandMetadata) {
+	subcmdMeta.Description = `Scaffold the webhooks of a Kubernetes API reconciled by a Go controller:
+	- the defaulting, validating and/or conversion webhooks are added next to the Go types under "api"
+	- the webhooks are registered with the manager in "main.go"
+	- the webhook server and its cert-manager certificate are enabled in "config/default"
+	- with --conversion, the CRD is patched under "config/crd" to use the conversion webhook
+
+Kinds reconciled by a Helm chart can not have webhooks, since the Helm reconciler does not
+register their Go types with the manager.
+`
+	subcmdMeta.Examples = fmt.Sprintf(`  # Create defaulting and validating webhooks for the Frigate kind
+	$ %[1]s create webhook --group ship --version v1beta1 --kind Frigate --defaulting --programmatic-validation
+
+	# Create a conversion webhook for the Frigate kind
+	$ %[1]s create webhook --group ship --version v1beta1 --kind Frigate --conversion
+`, cliMeta.CommandName)
+
+	p.commandName = cliMeta.CommandName
+}
+
+// BindFlags binds the flags used to create a webhook
+func (p *createWebhookSubcommand) BindFlags(fs *pflag.FlagSet) {
+	fs.SortFlags = false
+
+	fs.BoolVar(&p.defaulting, "defaulting", false, "if set, scaffold the defaulting webhook")
+	fs.BoolVar(&p.validation, "programmatic-validation", false, "if set, scaffold the validating webhook")
+	fs.BoolVar(&p.conversion, "conversion", false, "if set, scaffold the conversion webhook")
+
+	fs.BoolVar(&p.force, "force", false, "attempt to create the webhooks even if they already exist")
+}
+
+func (p *createWebhookSubcommand) InjectConfig(c config.Config) error {
+	p.config = c
+
+	return nil
+}
+
+func (p *createWebhookSubcommand) InjectResource(res *resource.Resource) error {
+	p.resource = res
+
+	if !p.defaulting && !p.validation && !p.conversion {
+		return fmt.Errorf("%s create webhook requires at least one of --defaulting, "+
+			"--programmatic-validation and --conversion to be set", p.commandName)
+	}
+
+	r, err := p.config.GetResource(p.resource.GVK)
+	if err != nil {
+		return fmt.Errorf("%s create webhook requires a previously created API", p.commandName)
+	}
+	if r.Webhooks != nil && !r.Webhooks.IsEmpty() && !p.force {
+		return errors.New("webhook resource already exists")
+	}
+
+	// Webhooks are registered using the Go types of the kind. The CRDs of the
+	// chart-backed kinds are not generated from their Go types, so webhooks are
+	// only scaffolded for kinds reconciled by a Go controller.
+	if r.Path == "" {
+		return fmt.Errorf("%s create webhook requires a kind with Go types, create the API with --%s=%s",
+			p.commandName, controllerTypeFlag, controllerTypeGo)
+	}
+	if !r.HasController() {
+		return fmt.Errorf("%s create webhook requires a kind reconciled by a Go controller, "+
+			"the CRDs of kinds reconciled by a Helm chart are not generated from their Go types", p.commandName)
+	}
+
+	p.resource.Path = r.Path
+	p.resource.Plural = r.Plural
+	p.resource.Webhooks.WebhookVersion = defaultWebhookVersion
+	p.resource.Webhooks.Defaulting = p.defaulting
+	p.resource.Webhooks.Validation = p.validation
+	p.resource.Webhooks.Conversion = p.conversion
+
+	if err := p.resource.Validate(); err != nil {
+		return err
+	}
+
+	if util.HasDifferentWebhookVersion(p.config, p.resource.Webhooks.WebhookVersion) {
+		return fmt.Errorf("only one webhook version can be used for all resources, cannot add %q",
+			p.resource.Webhooks.WebhookVersion)
+	}
+
+	return nil
+}
+
+func (p *createWebhookSubcommand) Scaffold(fs machinery.Filesystem) error {
+	scaffolder := scaffolds.NewWebhookScaffolder(p.config, *p.resource, p.force)
+	scaffolder.InjectFS(fs)
+	if err := scaffolder.Scaffold(); err != nil {
+		return err
+	}
+
+	// The webhook server and its certificate, scaffolded above, are left
+	// commented out in the default kustomization until a webhook is created.
+	if err := sdkutil.UpdateKustomizationsCreateWebhook(fs.FS); err != nil {
+		return fmt.Errorf("error updating kustomization.yaml files: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watches
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrAlreadyWatched is returned by Upsert if the kind of the watch is already watched
+var ErrAlreadyWatched = errors.New("kind is already watched")
+
+// Upsert adds w to the watches file data, or replaces the watch of its kind if overwrite
+// is true. When a watch is replaced, the fields that are not set in w are removed. The
+// comments of the file are kept, and the scaffold marker is moved to its end.
+func Upsert(data []byte, w Watch, overwrite bool) ([]byte, error) {
+	watches, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate([]Watch{w}); err != nil {
+		return nil, err
+	}
+
+	entry, err := encodeEntry(w)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+
+	// A file without watches is decoded without its comments, those are kept as a header
+	if len(watches) == 0 {
+		buf := &bytes.Buffer{}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line = strings.TrimSpace(line); strings.HasPrefix(line, "#") && line != Marker {
+				fmt.Fprintln(buf, line)
+			}
+		}
+		b, err := Marshal([]Watch{w})
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		fmt.Fprintln(buf, Marker)
+		return buf.Bytes(), nil
+	}
+
+	// The watches are decoded in the order of the items of the sequence
+	seq := doc.Content[0]
+	seq.Style = 0
+	index := -1
+	for i, existing := range watches {
+		if existing.GroupVersionKind() == w.GroupVersionKind() {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		seq.Content = append(seq.Content, entry)
+	} else {
+		if !overwrite {
+			return nil, fmt.Errorf("%w: %s with chart %s",
+				ErrAlreadyWatched, w.GroupVersionKind(), watches[index].ChartPath)
+		}
+		replaceMapping(seq.Content[index], entry)
+	}
+
+	removeMarker(doc)
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	fmt.Fprintln(buf, Marker)
+	return buf.Bytes(), nil
+}
+
+// encodeEntry returns the node of w as an item of the watches sequence
+func encodeEntry(w Watch) (*yaml.Node, error) {
+	b, err := Marshal([]Watch{w})
+	if err != nil {
+		return nil, err
+	}
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(b, doc); err != nil {
+		return nil, err
+	}
+	return doc.Content[0].Content[0], nil
+}
+
+// replaceMapping replaces the keys of dst, an existing watch, with the keys of src, the
+// encoding of the watch replacing it. Every field of a watch is set by Upsert, so the keys
+// missing from src are removed. The keys that are kept stay in their order, with their
+// comments, and the new ones are appended.
+func replaceMapping(dst, src *yaml.Node) {
+	values := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+
+	var content []*yaml.Node
+	for j := 0; j+1 < len(dst.Content); j += 2 {
+		key, old := dst.Content[j], dst.Content[j+1]
+		value, ok := values[key.Value]
+		if !ok {
+			continue
+		}
+		value.HeadComment, value.LineComment, value.FootComment =
+			old.HeadComment, old.LineComment, old.FootComment
+		content = append(content, key, value)
+		delete(values, key.Value)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if _, ok := values[src.Content[i].Value]; ok {
+			content = append(content, src.Content[i], src.Content[i+1])
+		}
+	}
+	dst.Content = content
+}
+
+// removeMarker removes the scaffold marker from the comments of n and its children
+func removeMarker(n *yaml.Node) {
+	n.HeadComment = removeMarkerLine(n.HeadComment)
+	n.LineComment = removeMarkerLine(n.LineComment)
+	n.FootComment = removeMarkerLine(n.FootComment)
+	for _, c := range n.Content {
+		removeMarker(c)
+	}
+}
+
+func removeMarkerLine(comment string) string {
+	if !strings.Contains(comment, Marker) {
+		return comment
+	}
+	var lines []string
+	for _, line := range strings.Split(comment, "\n") {
+		if strings.TrimSpace(line) != Marker {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
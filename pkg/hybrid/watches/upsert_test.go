@@ -0,0 +1,177 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watches
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const memcachedWatch = `- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached
+`
+
+func TestUpsert(t *testing.T) {
+	memcached := Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Memcached",
+		ChartPath: "helm-charts/memcached"}
+	redis := Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Redis", ChartPath: "helm-charts/redis"}
+
+	tests := []struct {
+		name      string
+		data      string
+		watch     Watch
+		overwrite bool
+		want      string
+		wantErr   string
+	}{
+		{
+			name:  "empty file",
+			data:  "",
+			watch: memcached,
+			want:  memcachedWatch + Marker + "\n",
+		},
+		{
+			name:  "file with the header and the marker",
+			data:  "# Use the 'create api' subcommand to add watches to this file.\n" + Marker + "\n",
+			watch: memcached,
+			want:  "# Use the 'create api' subcommand to add watches to this file.\n" + memcachedWatch + Marker + "\n",
+		},
+		{
+			name: "new kind after the existing ones",
+			data: "# Use the 'create api' subcommand to add watches to this file.\n" +
+				memcachedWatch + Marker + "\n",
+			watch: redis,
+			want: "# Use the 'create api' subcommand to add watches to this file.\n" + memcachedWatch +
+				`- group: cache.example.com
+  version: v1alpha1
+  kind: Redis
+  chart: helm-charts/redis
+` + Marker + "\n",
+		},
+		{
+			name: "new kind in a file without the marker",
+			data: memcachedWatch,
+			watch: Watch{Version: "v1", Kind: "ConfigMap", ChartPath: "helm-charts/configmap",
+				ReconcilePeriod: &metav1.Duration{Duration: time.Minute}},
+			want: memcachedWatch + `- group: ""
+  version: v1
+  kind: ConfigMap
+  chart: helm-charts/configmap
+  reconcilePeriod: 1m0s
+` + Marker + "\n",
+		},
+		{
+			name:    "kind already watched",
+			data:    memcachedWatch + Marker + "\n",
+			watch:   Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Memcached", ChartPath: "helm-charts/memcached-v2"},
+			wantErr: "kind is already watched: cache.example.com/v1alpha1, Kind=Memcached with chart helm-charts/memcached",
+		},
+		{
+			name: "overwrite, keeping the other watches and the comments",
+			data: `# Use the 'create api' subcommand to add watches to this file.
+# The memcached operator
+- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached # the upstream chart
+  maxConcurrentReconciles: 2
+# The redis operator
+- group: cache.example.com
+  version: v1alpha1
+  kind: Redis
+  chart: helm-charts/redis
+  reconcilePeriod: 5m
+` + Marker + "\n",
+			watch: Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Memcached",
+				ChartPath: "helm-charts/memcached-v2", MaxConcurrentReconciles: intPtr(4),
+				OverrideValues: map[string]string{"image.repository": "$RELATED_IMAGE"}},
+			overwrite: true,
+			want: `# Use the 'create api' subcommand to add watches to this file.
+# The memcached operator
+- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached-v2 # the upstream chart
+  maxConcurrentReconciles: 4
+  overrideValues:
+    image.repository: $RELATED_IMAGE
+# The redis operator
+- group: cache.example.com
+  version: v1alpha1
+  kind: Redis
+  chart: helm-charts/redis
+  reconcilePeriod: 5m
+` + Marker + "\n",
+		},
+		{
+			name: "overwrite, removing the options that are reset",
+			data: `- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached
+  watchDependentResources: false
+  overrideValues:
+    image.repository: $RELATED_IMAGE
+  reconcilePeriod: 1m
+  maxConcurrentReconciles: 2
+` + Marker + "\n",
+			watch:     memcached,
+			overwrite: true,
+			want:      memcachedWatch + Marker + "\n",
+		},
+		{
+			name:    "invalid watch",
+			data:    memcachedWatch + Marker + "\n",
+			watch:   Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Redis"},
+			wantErr: "chart must not be empty",
+		},
+		{
+			name:    "file with an unsupported option",
+			data:    memcachedWatch + "  selector:\n    matchLabels:\n      tier: frontend\n" + Marker + "\n",
+			watch:   redis,
+			wantErr: "selector is not supported by the Helm reconciler",
+		},
+		{
+			name:    "invalid file",
+			data:    memcachedWatch + memcachedWatch,
+			watch:   redis,
+			wantErr: "duplicate GVK",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := Upsert([]byte(tt.data), tt.watch, tt.overwrite)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if string(b) != tt.want {
+				t.Errorf("expected content:\n%s\ngot:\n%s", tt.want, b)
+			}
+			if _, err := Parse(b); err != nil {
+				t.Errorf("invalid watches file after upsert: %v", err)
+			}
+		})
+	}
+
+	if _, err := Upsert([]byte(memcachedWatch), memcached, false); !errors.Is(err, ErrAlreadyWatched) {
+		t.Errorf("expected ErrAlreadyWatched, got %v", err)
+	}
+}
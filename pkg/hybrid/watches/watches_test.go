@@ -0,0 +1,333 @@
+// Copyright 2021 The Operator-SDK Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watches
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"helm.sh/helm/v3/pkg/chart"
+	helmchartutil "helm.sh/helm/v3/pkg/chartutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// checkErr checks that err contains wantErr, or is nil if wantErr is empty.
+func checkErr(t *testing.T, err error, wantErr string) {
+	if wantErr == "" {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Fatalf("expected error containing %q, got %v", wantErr, err)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []Watch
+		wantErr string
+	}{
+		{
+			name: "every option",
+			data: `- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached
+  watchDependentResources: false
+  overrideValues:
+    image.repository: $RELATED_IMAGE
+  reconcilePeriod: 1m
+  maxConcurrentReconciles: 2
+`,
+			want: []Watch{{
+				Group:                   "cache.example.com",
+				Version:                 "v1alpha1",
+				Kind:                    "Memcached",
+				ChartPath:               "helm-charts/memcached",
+				WatchDependentResources: boolPtr(false),
+				OverrideValues:          map[string]string{"image.repository": "$RELATED_IMAGE"},
+				ReconcilePeriod:         &metav1.Duration{Duration: time.Minute},
+				MaxConcurrentReconciles: intPtr(2),
+			}},
+		},
+		{
+			name: "core kind",
+			data: "- version: v1\n  kind: ConfigMap\n  chart: helm-charts/configmap\n",
+			want: []Watch{{Version: "v1", Kind: "ConfigMap", ChartPath: "helm-charts/configmap"}},
+		},
+		{
+			name: "only comments",
+			data: "# Use the 'create api' subcommand to add watches to this file.\n" + Marker + "\n",
+			want: []Watch{},
+		},
+		{
+			name:    "unknown field",
+			data:    "- version: v1\n  kind: ConfigMap\n  chart: helm-charts/configmap\n  reconcilePeriods: 1m\n",
+			wantErr: `unknown field "reconcilePeriods"`,
+		},
+		{
+			name: "duplicate GVK",
+			data: `- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached
+- group: cache.example.com
+  version: v1alpha1
+  kind: Memcached
+  chart: helm-charts/memcached-v2
+`,
+			wantErr: "duplicate GVK: cache.example.com/v1alpha1, Kind=Memcached",
+		},
+		{
+			name:    "missing version",
+			data:    "- group: cache.example.com\n  kind: Memcached\n  chart: helm-charts/memcached\n",
+			wantErr: "version must not be empty",
+		},
+		{
+			name:    "missing kind",
+			data:    "- group: cache.example.com\n  version: v1alpha1\n  chart: helm-charts/memcached\n",
+			wantErr: "kind must not be empty",
+		},
+		{
+			name:    "missing chart",
+			data:    "- group: cache.example.com\n  version: v1alpha1\n  kind: Memcached\n",
+			wantErr: "chart must not be empty",
+		},
+		{
+			name: "selector",
+			data: `- version: v1
+  kind: ConfigMap
+  chart: helm-charts/configmap
+  selector:
+    matchLabels:
+      tier: frontend
+`,
+			wantErr: "selector is not supported by the Helm reconciler",
+		},
+		{
+			name:    "negative reconcilePeriod",
+			data:    "- version: v1\n  kind: ConfigMap\n  chart: helm-charts/configmap\n  reconcilePeriod: -1m\n",
+			wantErr: "reconcilePeriod -1m0s must not be negative",
+		},
+		{
+			name:    "zero maxConcurrentReconciles",
+			data:    "- version: v1\n  kind: ConfigMap\n  chart: helm-charts/configmap\n  maxConcurrentReconciles: 0\n",
+			wantErr: "maxConcurrentReconciles 0 must be at least 1",
+		},
+		{
+			name:    "negative maxConcurrentReconciles",
+			data:    "- version: v1\n  kind: ConfigMap\n  chart: helm-charts/configmap\n  maxConcurrentReconciles: -2\n",
+			wantErr: "maxConcurrentReconciles -2 must be at least 1",
+		},
+		{
+			name: "blacklist",
+			data: `- version: v1
+  kind: ConfigMap
+  chart: helm-charts/configmap
+  blacklist:
+  - group: apps
+    version: v1
+    kind: Deployment
+`,
+			wantErr: "blacklist is not supported by the Helm reconciler",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			watches, err := Parse([]byte(tt.data))
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr == "" && !reflect.DeepEqual(watches, tt.want) {
+				t.Errorf("expected watches:\n%+v\ngot:\n%+v", tt.want, watches)
+			}
+		})
+	}
+}
+
+// newChartsDir returns a directory with a chart named memcached.
+func newChartsDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "watches-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	chrt := &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "memcached", Version: "0.1.0"},
+	}
+	if err := helmchartutil.SaveDir(chrt, filepath.Join(dir, "helm-charts")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := newChartsDir(t)
+	if err := os.Setenv("WATCHES_TEST_IMAGE", "quay.io/example/memcached"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("WATCHES_TEST_IMAGE")
+
+	tests := []struct {
+		name      string
+		data      string
+		chartsDir string
+		wantErr   string
+	}{
+		{
+			name:      "relative chart path",
+			data:      "- version: v1\n  kind: Memcached\n  chart: helm-charts/memcached\n",
+			chartsDir: dir,
+		},
+		{
+			name: "absolute chart path",
+			data: "- version: v1\n  kind: Memcached\n  chart: " +
+				filepath.Join(dir, "helm-charts", "memcached") + "\n",
+			chartsDir: "another-dir",
+		},
+		{
+			name:      "missing chart directory",
+			data:      "- version: v1\n  kind: Memcached\n  chart: helm-charts/redis\n",
+			chartsDir: dir,
+			wantErr:   "chart directory " + filepath.Join(dir, "helm-charts", "redis") + " does not exist",
+		},
+		{
+			name:      "chart path relative to the working directory",
+			data:      "- version: v1\n  kind: Memcached\n  chart: helm-charts/memcached\n",
+			chartsDir: "",
+			wantErr:   "chart directory helm-charts/memcached does not exist",
+		},
+		{
+			name:      "invalid watch",
+			data:      "- version: v1\n  kind: Memcached\n",
+			chartsDir: dir,
+			wantErr:   "chart must not be empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "watches.yaml")
+			data := tt.data
+			if tt.wantErr == "" {
+				data += "  overrideValues:\n    image.repository: $WATCHES_TEST_IMAGE\n"
+			}
+			if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			watches, err := Load(path, tt.chartsDir)
+			checkErr(t, err, tt.wantErr)
+			if tt.wantErr != "" {
+				return
+			}
+			if len(watches) != 1 || watches[0].Chart == nil || watches[0].Chart.Name() != "memcached" {
+				t.Fatalf("expected a watch with the memcached chart, got %+v", watches)
+			}
+			if v := watches[0].OverrideValues["image.repository"]; v != "quay.io/example/memcached" {
+				t.Errorf("expected the override value to be expanded, got %q", v)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.yaml"), dir); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error for a missing watches file, got %v", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	memcached := Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Memcached",
+		ChartPath: "helm-charts/memcached"}
+	tests := []struct {
+		name    string
+		watches []Watch
+		want    string
+		wantErr string
+	}{
+		{
+			name: "no watches",
+			want: header + "\n" + Marker + "\n",
+		},
+		{
+			name: "watches with options",
+			watches: []Watch{
+				memcached,
+				{Version: "v1", Kind: "ConfigMap", ChartPath: "helm-charts/configmap",
+					WatchDependentResources: boolPtr(false),
+					OverrideValues:          map[string]string{"image.repository": "$RELATED_IMAGE"},
+					ReconcilePeriod:         &metav1.Duration{Duration: time.Minute}},
+			},
+			want: header + "\n" + memcachedWatch + `- group: ""
+  version: v1
+  kind: ConfigMap
+  chart: helm-charts/configmap
+  watchDependentResources: false
+  overrideValues:
+    image.repository: $RELATED_IMAGE
+  reconcilePeriod: 1m0s
+` + Marker + "\n",
+		},
+		{
+			name:    "duplicate watches",
+			watches: []Watch{memcached, memcached},
+			wantErr: "duplicate GVK",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			checkErr(t, Write(fs, "watches.yaml", tt.watches), tt.wantErr)
+			if tt.wantErr != "" {
+				if _, err := fs.Stat("watches.yaml"); !os.IsNotExist(err) {
+					t.Errorf("expected no watches file to be written, got %v", err)
+				}
+				return
+			}
+			b, err := afero.ReadFile(fs, "watches.yaml")
+			checkErr(t, err, "")
+			if string(b) != tt.want {
+				t.Errorf("expected content:\n%s\ngot:\n%s", tt.want, b)
+			}
+
+			// The watches of new kinds are added before the marker
+			redis := Watch{Group: "cache.example.com", Version: "v1alpha1", Kind: "Redis", ChartPath: "helm-charts/redis"}
+			b, err = Upsert(b, redis, false)
+			checkErr(t, err, "")
+			watches, err := Parse(b)
+			checkErr(t, err, "")
+			if want := append(tt.watches, redis); !reflect.DeepEqual(watches, want) {
+				t.Errorf("expected watches:\n%+v\ngot:\n%+v", want, watches)
+			}
+			if !strings.HasSuffix(string(b), Marker+"\n") {
+				t.Errorf("expected the marker at the end of the file, got:\n%s", b)
+			}
+		})
+	}
+}